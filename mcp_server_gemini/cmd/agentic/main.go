@@ -0,0 +1,122 @@
+package main
+
+// Example of using MCP with Gemini via Function Calls
+// Uses an agentic approach: tools are called until the model gives a final answer
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/joho/godotenv"
+	"google.golang.org/api/option"
+
+	"example.com/mcp-server/mcpgemini"
+	"example.com/mcp-server/mcpgemini/history"
+)
+
+func main() {
+	maxSteps := flag.Int("max-steps", mcpgemini.DefaultMaxSteps, "maximum number of tool rounds")
+	parallelism := flag.Int("parallelism", mcpgemini.DefaultParallelism, "maximum number of concurrent tool calls")
+	callTimeout := flag.Duration("call-timeout", mcpgemini.DefaultCallTimeout, "timeout for a single tool call")
+	sessionID := flag.String("session", "", "resume and save the conversation under this session id")
+	storeKind := flag.String("store", "file", "history store backend: file or sqlite")
+	storePath := flag.String("store-path", "", "history store directory or database file")
+	tokenBudget := flag.Int("token-budget", 0, "trim the chat history to this many tokens, 0 keeps everything")
+	summarize := flag.Bool("summarize", false, "summarise trimmed history instead of dropping it")
+	serverCommand := flag.String("server", "", "command starting the MCP server, defaults to $"+mcpgemini.ServerCommandEnv+", bin/server or go run ./server")
+	flag.Parse()
+
+	// Load dotenv file
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("error loading dotenv file")
+	}
+
+	ctx := context.Background()
+
+	// Start the server process
+	serverCfg, err := mcpgemini.ResolveServer(*serverCommand)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := mcpgemini.NewSupervisor("server", serverCfg.Cmd)
+	if err := server.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+	defer server.Close()
+	mcpgemini.CloseOnSignal(server)
+
+	tools, err := server.ListTools(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	geminiClient, err := genai.NewClient(ctx, option.WithAPIKey(os.Getenv("API_KEY")))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer geminiClient.Close()
+
+	model := geminiClient.GenerativeModel("gemini-2.5-pro-preview-03-25")
+	model.SetTemperature(0.0)
+
+	provider := mcpgemini.NewGeminiProvider(model)
+	if err := provider.SetTools(tools); err != nil {
+		log.Fatal(err)
+	}
+	if *tokenBudget > 0 {
+		provider.History = mcpgemini.NewHistoryManager(model, int32(*tokenBudget))
+		provider.History.Summarize = *summarize
+	}
+	session := provider.Session
+
+	var store history.Store
+	if *sessionID != "" {
+		store, err = history.Open(*storeKind, *storePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		session.History, err = store.Load(ctx, *sessionID)
+		if err != nil && !errors.Is(err, history.ErrNotFound) {
+			log.Fatalf("failed to load session %s: %v", *sessionID, err)
+		}
+		log.Printf("session %s: resuming with %d messages", *sessionID, len(session.History))
+	}
+	prompt := "Compare the current Bitcoin price in EUR and GBP, then say hello to Alice. Only provide your answer in a natural language response."
+
+	agent := mcpgemini.NewAgent(provider, server)
+	agent.MaxSteps = *maxSteps
+	agent.Parallelism = *parallelism
+	agent.CallTimeout = *callTimeout
+
+	result, err := agent.Run(ctx, prompt)
+	// A failed run may leave an unanswered function call in the history,
+	// which would break every later resume of the session.
+	if store != nil && err == nil {
+		if err := store.Save(ctx, *sessionID, session.History); err != nil {
+			log.Printf("failed to save session %s: %v", *sessionID, err)
+		}
+	}
+	for i, step := range result.Steps {
+		fmt.Printf("step %d: %s\n", i+1, step.Text)
+		for _, call := range step.Calls {
+			fmt.Printf("  %s(%v) -> %v (%s)\n", call.Call.Name, call.Call.Args, call.Response, call.Duration)
+		}
+	}
+	if err != nil {
+		if result.Text != "" {
+			fmt.Println(result.Text)
+		}
+		log.Fatalf("agent.Run: %v", err)
+	}
+
+	fmt.Println(result.Text)
+	fmt.Println("---")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// serverModule is the module the bundled servers are built from.
+const serverModule = "example.com/mcp-server"
+
+// bundledServers maps the binaries built by the build subcommand to the
+// packages of the servers shipped with this module.
+var bundledServers = map[string]string{
+	"server": serverModule + "/server",
+}
+
+// runBuild compiles the bundled servers into a bin directory, so the
+// client can start them without the Go toolchain. The output directory is
+// relative to the current directory, which may be outside the module.
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	out := fs.String("o", "bin", "directory to write the server binaries to")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: client build [-o dir]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	dir, err := filepath.Abs(*out)
+	if err != nil {
+		return err
+	}
+	root, err := moduleDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	for name, pkg := range bundledServers {
+		target := filepath.Join(dir, name)
+		cmd := exec.Command("go", "build", "-o", target, pkg)
+		cmd.Dir = root
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("error building %s: %w", pkg, err)
+		}
+		log.Printf("built %s", target)
+	}
+	return nil
+}
+
+// moduleDir returns the directory of serverModule. It looks from the
+// current directory, then from the client binary, which is usually in the
+// bin directory of the module, then from the client sources for go run.
+func moduleDir() (string, error) {
+	var candidates []string
+	if wd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, wd)
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Dir(exe))
+	}
+	if _, file, _, ok := runtime.Caller(0); ok {
+		candidates = append(candidates, filepath.Dir(file))
+	}
+
+	for _, dir := range candidates {
+		cmd := exec.Command("go", "list", "-m", "-f", "{{.Path}} {{.Dir}}")
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		// A workspace lists each of its modules on a line
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if path, root, ok := strings.Cut(line, " "); ok && path == serverModule {
+				return root, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot find the sources of %s, run the build from its directory", serverModule)
+}
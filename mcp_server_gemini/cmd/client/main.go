@@ -0,0 +1,313 @@
+package main
+
+// Example of using MCP with Gemini via Function Calls
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/joho/godotenv"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+	"google.golang.org/api/option"
+
+	"example.com/mcp-server/mcpgemini"
+	"example.com/mcp-server/mcpgemini/cassette"
+	"example.com/mcp-server/mcpgemini/fake"
+	"example.com/mcp-server/mcpgemini/history"
+	"example.com/mcp-server/mcpgemini/openai"
+	"example.com/mcp-server/mcpgemini/streamhttp"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "build" {
+		if err := runBuild(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	interactive := flag.Bool("i", false, "start an interactive chat session")
+	prompt := flag.String("prompt", "What's the current Bitcoin price in RUB?", "question to ask in non-interactive mode")
+	stream := flag.Bool("stream", false, "print the answer as it is generated")
+	providerName := flag.String("provider", "gemini", "model provider: gemini, openai or fake")
+	script := flag.String("script", "", "YAML or JSON script played by the fake provider")
+	modelName := flag.String("model", "", "model name, defaults to gemini-2.5-pro-preview-03-25 for gemini")
+	baseURL := flag.String("base-url", "http://localhost:8000/v1", "base URL of the OpenAI-compatible API")
+	sessionID := flag.String("session", "", "resume and save the conversation under this session id")
+	storeKind := flag.String("store", "file", "history store backend: file or sqlite")
+	storePath := flag.String("store-path", "", "history store directory or database file")
+	tokenBudget := flag.Int("token-budget", 0, "trim the chat history to this many tokens, 0 keeps everything")
+	summarize := flag.Bool("summarize", false, "summarise trimmed history instead of dropping it")
+	record := flag.String("record", "", "record the model and MCP traffic into this cassette file")
+	replay := flag.String("replay", "", "replay a cassette file instead of running the model and server")
+	url := flag.String("url", "", "connect to a running MCP server over HTTP, e.g. http://localhost:8080/mcp")
+	attach := flag.String("attach", "", "comma separated resource URIs to attach to the prompt")
+	usePrompt := flag.String("use-prompt", "", "server prompt inserted before the question, e.g. \"market_brief currency=EUR\"")
+	config := flag.String("config", "", "JSON file listing the MCP servers to start instead of ./server")
+	serverCommand := flag.String("server", "", "command starting the MCP server, defaults to $"+mcpgemini.ServerCommandEnv+", bin/server or go run ./server")
+	flag.Parse()
+
+	if *record != "" && *replay != "" {
+		log.Fatal("-record and -replay cannot be used together")
+	}
+	if *config != "" && (*record != "" || *replay != "" || *url != "") {
+		log.Fatal("-record, -replay and -url only support a single server, not -config")
+	}
+	if *url != "" && *replay != "" {
+		log.Fatal("-url and -replay cannot be used together")
+	}
+
+	// Load dotenv file, the fake provider and replays need no API keys
+	err := godotenv.Load()
+	if err != nil && *providerName != "fake" && *replay == "" {
+		log.Fatal("error loading dotenv file")
+	}
+
+	ctx := context.Background()
+
+	var tape *cassette.Cassette
+	var toolbox mcpgemini.Toolbox
+	if *config != "" {
+		cfg, err := mcpgemini.LoadHostConfig(*config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		host, err := mcpgemini.StartHost(ctx, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer host.Close()
+		mcpgemini.CloseOnSignal(host)
+		toolbox = host
+	} else if *url != "" {
+		var t transport.Transport = streamhttp.NewClient(*url)
+		if *record != "" {
+			tape = cassette.New()
+			t = tape.RecordTransport(t)
+		}
+		bridge, err := mcpgemini.Connect(ctx, t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		toolbox = bridge
+	} else if *replay != "" {
+		tape, err = cassette.Load(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bridge, err := mcpgemini.Connect(ctx, tape.ReplayTransport())
+		if err != nil {
+			log.Fatal(err)
+		}
+		toolbox = bridge
+	} else {
+		// Start the server process and restart it if it crashes
+		serverCfg, err := mcpgemini.ResolveServer(*serverCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		server := mcpgemini.NewSupervisor("server", serverCfg.Cmd)
+		if *record != "" {
+			tape = cassette.New()
+			server.Wrap = tape.RecordTransport
+		}
+		if err := server.Start(ctx); err != nil {
+			log.Fatal(err)
+		}
+		defer server.Close()
+		mcpgemini.CloseOnSignal(server)
+		toolbox = server
+	}
+	saveTape := func() {
+		if *record == "" {
+			return
+		}
+		if err := tape.Save(*record); err != nil {
+			log.Printf("failed to save cassette %s: %v", *record, err)
+		}
+	}
+
+	tools, err := toolbox.ListTools(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Available tools:")
+	for _, tool := range tools {
+		desc := ""
+		if tool.Description != nil {
+			desc = *tool.Description
+		}
+		log.Printf("Tool: %s. Description: %s", tool.Name, desc)
+	}
+
+	// Chat history features need a Gemini session
+	var gemini *mcpgemini.GeminiProvider
+	var provider mcpgemini.Provider
+	var scripted *fake.Provider
+	var player *cassette.Player
+	switch {
+	case *replay != "":
+		if *sessionID != "" || *tokenBudget > 0 {
+			log.Fatal("-session and -token-budget are only supported with the gemini provider")
+		}
+		player = tape.Player()
+		provider = player
+	case *providerName == "gemini":
+		geminiClient, err := genai.NewClient(ctx, option.WithAPIKey(os.Getenv("API_KEY")))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer geminiClient.Close()
+
+		name := *modelName
+		if name == "" {
+			name = "gemini-2.5-pro-preview-03-25"
+		}
+		model := geminiClient.GenerativeModel(name)
+		model.SetTemperature(0.1)
+
+		gemini = mcpgemini.NewGeminiProvider(model)
+		if *tokenBudget > 0 {
+			gemini.History = mcpgemini.NewHistoryManager(model, int32(*tokenBudget))
+			gemini.History.Summarize = *summarize
+		}
+		provider = gemini
+	case *providerName == "openai":
+		if *sessionID != "" || *tokenBudget > 0 {
+			log.Fatal("-session and -token-budget are only supported with the gemini provider")
+		}
+		provider = openai.New(*baseURL, os.Getenv("OPENAI_API_KEY"), *modelName)
+	case *providerName == "fake":
+		if *sessionID != "" || *tokenBudget > 0 {
+			log.Fatal("-session and -token-budget are only supported with the gemini provider")
+		}
+		s, err := fake.Load(*script)
+		if err != nil {
+			log.Fatal(err)
+		}
+		scripted = fake.New(s)
+		provider = scripted
+	default:
+		log.Fatalf("unknown provider %s", *providerName)
+	}
+	if *record != "" {
+		provider = tape.Recorder(provider)
+	}
+
+	if err := provider.SetTools(tools); err != nil {
+		log.Fatal(err)
+	}
+	agent := mcpgemini.NewAgent(provider, toolbox)
+
+	// Only sessions and the interactive chat of the Gemini provider use
+	// the store, so other runs neither create it nor need cgo for SQLite.
+	var store history.Store
+	if gemini != nil && (*sessionID != "" || *interactive) {
+		store, err = history.Open(*storeKind, *storePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+	}
+
+	if *sessionID != "" {
+		gemini.Session.History, err = store.Load(ctx, *sessionID)
+		if err != nil && !errors.Is(err, history.ErrNotFound) {
+			log.Fatalf("failed to load session %s: %v", *sessionID, err)
+		}
+	}
+
+	resources, _ := toolbox.(mcpgemini.ResourceReader)
+	if *attach != "" {
+		if resources == nil {
+			log.Fatal("-attach: the server cannot read resources")
+		}
+		for _, uri := range strings.Split(*attach, ",") {
+			contents, err := resources.ReadResource(ctx, strings.TrimSpace(uri))
+			if err != nil {
+				log.Fatal(err)
+			}
+			agent.Attach(contents...)
+		}
+	}
+
+	prompter, _ := toolbox.(mcpgemini.Prompter)
+	var prompts []*mcp_golang.PromptSchema
+	if prompter != nil {
+		prompts, err = prompter.ListPrompts(ctx)
+		if err != nil {
+			log.Printf("failed to list prompts: %v", err)
+		}
+	}
+	if *usePrompt != "" {
+		messages, err := expandPrompt(ctx, prompter, prompts, *usePrompt)
+		if err != nil {
+			log.Fatal(err)
+		}
+		agent.InsertPrompt(messages...)
+	}
+
+	if *interactive {
+		r := &repl{
+			agent:     agent,
+			gemini:    gemini,
+			tools:     tools,
+			resources: resources,
+			prompter:  prompter,
+			prompts:   prompts,
+			stream:    *stream,
+			store:     store,
+			sessionID: *sessionID,
+			in:        os.Stdin,
+			out:       os.Stdout,
+		}
+		if err := r.run(ctx); err != nil {
+			log.Printf("error reading input: %v", err)
+		}
+		saveTape()
+		return
+	}
+
+	agent.OnToolCall = func(call mcpgemini.ToolCall) {
+		log.Printf("tool call: %+v, response: %v\n", call.Call, call.Response)
+	}
+
+	if *stream {
+		_, err = agent.RunStream(ctx, *prompt, func(text string) {
+			fmt.Print(text)
+		})
+		fmt.Println()
+	} else {
+		var result *mcpgemini.Result
+		result, err = agent.Run(ctx, *prompt)
+		if err == nil || result.Text != "" {
+			fmt.Println(result.Text)
+		}
+	}
+	// A failed run may leave an unanswered function call in the history,
+	// which would break every later resume of the session.
+	if *sessionID != "" && err == nil {
+		if err := store.Save(ctx, *sessionID, gemini.Session.History); err != nil {
+			log.Printf("failed to save session %s: %v", *sessionID, err)
+		}
+	}
+	if err != nil {
+		saveTape()
+		log.Fatalf("agent.Run: %v", err)
+	}
+	if scripted != nil && scripted.Remaining() > 0 {
+		log.Fatalf("fake provider: %d scripted turns were not played", scripted.Remaining())
+	}
+	if player != nil && player.Remaining() > 0 {
+		log.Fatalf("replay: %d recorded turns were not played", player.Remaining())
+	}
+	saveTape()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+// expandPrompt expands a prompt invocation such as
+// `market_brief currency=EUR style="one line"` into its messages, checking
+// the arguments against the prompt's schema.
+func expandPrompt(ctx context.Context, prompter mcpgemini.Prompter, prompts []*mcp_golang.PromptSchema, line string) ([]mcpgemini.PromptMessage, error) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	schema := findPrompt(prompts, name)
+	if schema == nil {
+		return nil, fmt.Errorf("unknown prompt %s", name)
+	}
+
+	parsed, err := parsePromptArgs(rest)
+	if err != nil {
+		return nil, err
+	}
+	// Argument names are matched ignoring case, as mcp-golang names them
+	// after the Go fields of the server's argument struct.
+	args := map[string]string{}
+	for key, value := range parsed {
+		found := false
+		for _, arg := range schema.Arguments {
+			if strings.EqualFold(arg.Name, key) {
+				args[arg.Name], found = value, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("prompt %s: unknown argument %s, usage: %s", name, key, promptUsage(schema))
+		}
+	}
+	for _, arg := range schema.Arguments {
+		if arg.Required != nil && *arg.Required && args[arg.Name] == "" {
+			return nil, fmt.Errorf("prompt %s: missing argument %s, usage: %s", name, arg.Name, promptUsage(schema))
+		}
+	}
+
+	return prompter.GetPrompt(ctx, name, args)
+}
+
+func findPrompt(prompts []*mcp_golang.PromptSchema, name string) *mcp_golang.PromptSchema {
+	for _, p := range prompts {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// promptUsage renders the slash command of a prompt, with optional
+// arguments in brackets.
+func promptUsage(p *mcp_golang.PromptSchema) string {
+	var b strings.Builder
+	b.WriteString("/" + p.Name)
+	for _, arg := range p.Arguments {
+		if arg.Required != nil && *arg.Required {
+			fmt.Fprintf(&b, " %s=...", arg.Name)
+		} else {
+			fmt.Fprintf(&b, " [%s=...]", arg.Name)
+		}
+	}
+	return b.String()
+}
+
+// parsePromptArgs parses space separated key=value pairs. Values may be
+// double quoted to contain spaces.
+func parsePromptArgs(s string) (map[string]string, error) {
+	args := map[string]string{}
+	s = strings.TrimSpace(s)
+	for s != "" {
+		key, rest, found := strings.Cut(s, "=")
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", strings.Fields(s)[0])
+		}
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in argument %s", key)
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+		args[key] = value
+		s = strings.TrimSpace(rest)
+	}
+	return args, nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/mcpgemini"
+	"example.com/mcp-server/mcpgemini/history"
+)
+
+const replHelp = `Commands:
+  /tools    list the tools available to the model
+  /resources list the resources of the server
+  /read     <uri> print a resource
+  /attach   <uri> attach a resource to the next question
+  /prompts  list the prompts of the server, run one with /<prompt> key=value ...
+  /history  show the conversation so far
+  /reset    start a new conversation
+  /sessions list saved sessions
+  /resume   <id> switch to a saved session, or start it if it does not exist
+  /delete   <id> delete a saved session
+  /help     show this help
+  /exit     quit`
+
+// repl reads user turns from in and answers them with the agent, keeping
+// the chat history across turns. When sessionID is set the history is
+// saved to store after every turn; a failed turn is rolled back instead.
+// Sessions and /history need the Gemini provider and are unavailable when
+// gemini is nil. Every prompt of the server is also a command, which
+// inserts the prompt's messages before the next question.
+type repl struct {
+	agent     *mcpgemini.Agent
+	gemini    *mcpgemini.GeminiProvider
+	tools     []mcp_golang.ToolRetType
+	resources mcpgemini.ResourceReader
+	prompter  mcpgemini.Prompter
+	prompts   []*mcp_golang.PromptSchema
+	stream    bool
+	store     history.Store
+	sessionID string
+	in        io.Reader
+	out       io.Writer
+}
+
+func (r *repl) run(ctx context.Context) error {
+	fmt.Fprintln(r.out, "Type a question, or /help for commands.")
+	r.agent.OnToolCall = func(call mcpgemini.ToolCall) {
+		fmt.Fprintf(r.out, "[tool] %s(%v) -> %v\n", call.Call.Name, call.Call.Args, call.Response)
+	}
+
+	scanner := bufio.NewScanner(r.in)
+	for {
+		fmt.Fprint(r.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			if quit := r.command(ctx, line); quit {
+				return nil
+			}
+			continue
+		}
+
+		var restore func()
+		if cp, ok := r.agent.Provider.(mcpgemini.CheckpointProvider); ok {
+			restore = cp.Checkpoint()
+		}
+
+		var err error
+		if r.stream {
+			_, err = r.agent.RunStream(ctx, line, func(text string) {
+				fmt.Fprint(r.out, text)
+			})
+			fmt.Fprintln(r.out)
+		} else {
+			var result *mcpgemini.Result
+			result, err = r.agent.Run(ctx, line)
+			if err == nil || result.Text != "" {
+				fmt.Fprintln(r.out, result.Text)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+			r.rollback(restore)
+			continue
+		}
+		r.save(ctx)
+	}
+}
+
+// rollback restores the conversation from before a failed question, which
+// may end with a function call nothing answered, so that the session can
+// go on and is not saved broken.
+func (r *repl) rollback(restore func()) {
+	if restore == nil {
+		return
+	}
+	restore()
+	fmt.Fprintln(r.out, "the question was dropped from the conversation")
+}
+
+// save stores the current history under the active session, if any.
+func (r *repl) save(ctx context.Context) {
+	if r.sessionID == "" || r.gemini == nil {
+		return
+	}
+	if err := r.store.Save(ctx, r.sessionID, r.gemini.Session.History); err != nil {
+		fmt.Fprintf(r.out, "error saving session %s: %v\n", r.sessionID, err)
+	}
+}
+
+// command handles a slash command and reports whether the REPL should exit.
+func (r *repl) command(ctx context.Context, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	if (name == "/resume" || name == "/delete" || name == "/read" || name == "/attach") && arg == "" {
+		fmt.Fprintf(r.out, "usage: %s <id>\n", name)
+		return false
+	}
+	if r.resources == nil {
+		switch name {
+		case "/resources", "/read", "/attach":
+			fmt.Fprintf(r.out, "%s: the server cannot read resources\n", name)
+			return false
+		}
+	}
+	if r.gemini == nil {
+		switch name {
+		case "/history", "/sessions", "/resume", "/delete":
+			fmt.Fprintf(r.out, "%s is only supported with the gemini provider\n", name)
+			return false
+		}
+	}
+	switch name {
+	case "/exit", "/quit":
+		return true
+	case "/help":
+		fmt.Fprintln(r.out, replHelp)
+	case "/tools":
+		for _, tool := range r.tools {
+			desc := ""
+			if tool.Description != nil {
+				desc = *tool.Description
+			}
+			fmt.Fprintf(r.out, "%s: %s\n", tool.Name, desc)
+		}
+	case "/resources":
+		list, err := r.resources.ListResources(ctx)
+		if err != nil {
+			fmt.Fprintf(r.out, "error listing resources: %v\n", err)
+			break
+		}
+		for _, res := range list {
+			desc := ""
+			if res.Description != nil {
+				desc = *res.Description
+			}
+			fmt.Fprintf(r.out, "%s\t%s\t%s\n", res.Uri, res.Name, desc)
+		}
+	case "/read", "/attach":
+		contents, err := r.resources.ReadResource(ctx, arg)
+		if err != nil {
+			fmt.Fprintf(r.out, "error reading %s: %v\n", arg, err)
+			break
+		}
+		if name == "/attach" {
+			r.agent.Attach(contents...)
+			fmt.Fprintf(r.out, "attached %s to the next question\n", arg)
+			break
+		}
+		for _, res := range contents {
+			fmt.Fprintln(r.out, res.ContextText())
+		}
+	case "/prompts":
+		if len(r.prompts) == 0 {
+			fmt.Fprintln(r.out, "the server has no prompts")
+		}
+		for _, p := range r.prompts {
+			desc := ""
+			if p.Description != nil {
+				desc = *p.Description
+			}
+			fmt.Fprintf(r.out, "%s: %s\n", promptUsage(p), desc)
+		}
+	case "/history":
+		for _, content := range r.gemini.Session.History {
+			for _, part := range content.Parts {
+				fmt.Fprintf(r.out, "%s: %s\n", content.Role, mcpgemini.DescribePart(part))
+			}
+		}
+	case "/reset":
+		r.agent.Provider.Reset()
+		fmt.Fprintln(r.out, "conversation cleared")
+	case "/sessions":
+		sessions, err := r.store.List(ctx)
+		if err != nil {
+			fmt.Fprintf(r.out, "error listing sessions: %v\n", err)
+			break
+		}
+		for _, s := range sessions {
+			fmt.Fprintf(r.out, "%s\t%d messages\t%s\n", s.ID, s.Messages, s.UpdatedAt.Format(time.RFC1123))
+		}
+	case "/resume":
+		contents, err := r.store.Load(ctx, arg)
+		if err != nil && !errors.Is(err, history.ErrNotFound) {
+			fmt.Fprintf(r.out, "error loading session %s: %v\n", arg, err)
+			break
+		}
+		r.sessionID = arg
+		r.gemini.Session.History = contents
+		fmt.Fprintf(r.out, "session %s: %d messages\n", arg, len(contents))
+	case "/delete":
+		if err := r.store.Delete(ctx, arg); err != nil {
+			fmt.Fprintf(r.out, "error deleting session %s: %v\n", arg, err)
+			break
+		}
+		if arg == r.sessionID {
+			r.sessionID = ""
+		}
+		fmt.Fprintf(r.out, "session %s deleted\n", arg)
+	default:
+		if findPrompt(r.prompts, name[1:]) == nil {
+			fmt.Fprintf(r.out, "unknown command %s, try /help\n", name)
+			break
+		}
+		messages, err := expandPrompt(ctx, r.prompter, r.prompts, line[1:])
+		if err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+			break
+		}
+		r.agent.InsertPrompt(messages...)
+		for _, m := range messages {
+			fmt.Fprintf(r.out, "[%s] %s\n", m.Role, m.ContextText())
+		}
+		fmt.Fprintf(r.out, "inserted %d messages of %s before the next question\n", len(messages), name[1:])
+	}
+	return false
+}
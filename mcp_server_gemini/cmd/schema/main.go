@@ -0,0 +1,51 @@
+package main
+
+// Converts a sample MCP input schema into a Gemini schema and prints the result
+
+import (
+	"fmt"
+	"log"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+func main() {
+	// map[$schema:https://json-schema.org/draft/2020-12/schema properties:map[currency:map[description:The currency to get the Bitcoin price in (USD type:string]] required:[currency] type:object]
+
+	dict := make(map[string]interface{})
+	dict["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	dict["properties"] = map[string]interface{}{"currency": map[string]interface{}{"description": "The currency to get the Bitcoin price in (USD, EUR, GBP, etc)", "type": "string", "enum": []string{"USD", "EUR", "GBP"}}}
+	dict["properties"].(map[string]interface{})["filters"] = map[string]interface{}{
+		"description": "Filters to apply",
+		"type":        "array",
+		"items":       map[string]interface{}{"$ref": "#/$defs/Filter"},
+	}
+	dict["properties"].(map[string]interface{})["note"] = map[string]interface{}{
+		"description": "Optional note",
+		"oneOf":       []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "null"}},
+	}
+	dict["$defs"] = map[string]interface{}{
+		"Filter": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"field": map[string]interface{}{"description": "Field to filter on", "type": "string"},
+				"value": map[string]interface{}{"description": "Value to match", "type": "string"},
+				"and":   map[string]interface{}{"$ref": "#/$defs/Filter"},
+			},
+			"required": []string{"field"},
+		},
+	}
+	dict["required"] = []string{"currency"}
+	dict["type"] = "object"
+
+	res, warnings, err := mcpgemini.ConvertInputSchema(dict)
+	if err != nil {
+		panic(err)
+	}
+	for _, warning := range warnings {
+		log.Printf("WARNING: %s\n", warning)
+	}
+	fmt.Printf("RES: %+v\n", res)
+	fmt.Printf("FILTER: %+v\n", res.Properties["filters"].Items)
+	fmt.Printf("NOTE: %+v\n", res.Properties["note"])
+}
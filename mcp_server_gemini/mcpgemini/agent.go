@@ -0,0 +1,260 @@
+package mcpgemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// DefaultMaxSteps is the number of tool rounds an Agent allows when
+// MaxSteps is not set.
+const DefaultMaxSteps = 10
+
+// DefaultParallelism is the number of tool calls an Agent runs at once
+// when Parallelism is not set.
+const DefaultParallelism = 4
+
+// DefaultCallTimeout bounds a single tool call when CallTimeout is not set.
+const DefaultCallTimeout = 30 * time.Second
+
+// ErrMaxSteps is returned when the model keeps asking for tools after the
+// agent has used up its step budget.
+var ErrMaxSteps = errors.New("agent reached the maximum number of steps")
+
+// stepLimitResponse answers the tool calls the model makes past MaxSteps.
+var stepLimitResponse = map[string]any{"error": "step limit reached, answer without calling more tools"}
+
+// maxDeclineRounds bounds how many times calls past MaxSteps are declined
+// while waiting for the model to answer with text.
+const maxDeclineRounds = 3
+
+// ToolCall is a tool call made by the model and the response the MCP
+// server returned for it.
+type ToolCall struct {
+	Call     Call
+	Response map[string]any
+	Duration time.Duration
+}
+
+// Step is a single model turn that requested one or more tools.
+type Step struct {
+	// Text is any text the model returned alongside its tool calls.
+	Text  string
+	Calls []ToolCall
+}
+
+// Result describes a complete agent run.
+type Result struct {
+	// Text is the final answer of the model.
+	Text  string
+	Steps []Step
+}
+
+// Toolbox is a set of MCP tools the agent can call. It is implemented by
+// Bridge for a single server and by Host for several.
+type Toolbox interface {
+	ListTools(ctx context.Context) ([]mcp_golang.ToolRetType, error)
+	CallTool(ctx context.Context, name string, args map[string]any) map[string]any
+}
+
+// Agent drives a conversation with a Provider, executing the tools the
+// model asks for until it returns a final text answer.
+type Agent struct {
+	Provider Provider
+	Tools    Toolbox
+	// MaxSteps bounds the number of tool rounds in a single Run.
+	MaxSteps int
+	// Parallelism bounds the number of tool calls of a single turn that
+	// are dispatched to the MCP server concurrently.
+	Parallelism int
+	// CallTimeout bounds each individual tool call.
+	CallTimeout time.Duration
+	// OnToolCall, if set, is called as soon as each tool call completes.
+	// It may be called from several goroutines at once.
+	OnToolCall func(ToolCall)
+
+	attached []Resource
+	inserted []PromptMessage
+}
+
+// NewAgent returns an Agent using the default step limit.
+func NewAgent(provider Provider, tools Toolbox) *Agent {
+	return &Agent{
+		Provider:    provider,
+		Tools:       tools,
+		MaxSteps:    DefaultMaxSteps,
+		Parallelism: DefaultParallelism,
+		CallTimeout: DefaultCallTimeout,
+	}
+}
+
+// Attach adds resources to the context of the next prompt sent by Run or
+// RunStream.
+func (a *Agent) Attach(resources ...Resource) {
+	a.attached = append(a.attached, resources...)
+}
+
+// InsertPrompt adds the messages of an MCP prompt to the conversation
+// before the next prompt sent by Run or RunStream.
+func (a *Agent) InsertPrompt(messages ...PromptMessage) {
+	a.inserted = append(a.inserted, messages...)
+}
+
+// prompt returns the message for prompt with the inserted prompt messages
+// and attached resources, and clears them.
+func (a *Agent) prompt(prompt string) Message {
+	msg := Message{Prompt: a.inserted, Text: prompt, Resources: a.attached}
+	a.inserted, a.attached = nil, nil
+	return msg
+}
+
+// Run sends prompt to the model and keeps answering its tool calls until
+// it stops asking for tools. The partial result is returned along with
+// any error so callers can inspect the steps taken so far. Calls made
+// past MaxSteps are answered with an error rather than run, so the
+// conversation can go on, and ErrMaxSteps is returned along with whatever
+// text the model then answers.
+func (a *Agent) Run(ctx context.Context, prompt string) (*Result, error) {
+	maxSteps := a.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	res := &Result{}
+	msg := a.prompt(prompt)
+	for {
+		reply, err := a.Provider.Send(ctx, msg)
+		if err != nil {
+			return res, err
+		}
+		if len(reply.Calls) == 0 {
+			res.Text = reply.Text
+			return res, nil
+		}
+		if len(res.Steps) >= maxSteps {
+			text, err := a.decline(reply.Calls, func(msg Message) (*Reply, error) {
+				return a.Provider.Send(ctx, msg)
+			})
+			res.Text = text
+			return res, errors.Join(ErrMaxSteps, err)
+		}
+
+		step := Step{Text: reply.Text, Calls: a.callAll(ctx, reply.Calls)}
+		res.Steps = append(res.Steps, step)
+		msg = resultsMessage(step)
+	}
+}
+
+// decline answers calls with stepLimitResponse through send, so that the
+// provider history does not end with unanswered calls, and returns the
+// text the model answers with. Calls the model keeps making are declined
+// too, up to maxDeclineRounds times.
+func (a *Agent) decline(calls []Call, send func(Message) (*Reply, error)) (string, error) {
+	for range maxDeclineRounds {
+		msg := Message{Results: make([]CallResult, len(calls))}
+		for i, call := range calls {
+			msg.Results[i] = CallResult{Call: call, Response: stepLimitResponse}
+		}
+		reply, err := send(msg)
+		if err != nil {
+			return "", fmt.Errorf("error declining calls past the step limit: %w", err)
+		}
+		if len(reply.Calls) == 0 {
+			return reply.Text, nil
+		}
+		calls = reply.Calls
+	}
+	return "", fmt.Errorf("model kept calling tools after %d declined rounds", maxDeclineRounds)
+}
+
+// resultsMessage returns the message answering the tool calls of step.
+func resultsMessage(step Step) Message {
+	msg := Message{Results: make([]CallResult, len(step.Calls))}
+	for i, call := range step.Calls {
+		msg.Results[i] = CallResult{Call: call.Call, Response: call.Response}
+	}
+	return msg
+}
+
+// callAll dispatches calls to the MCP server and waits for all of them.
+// Results are returned in the same order as calls so that the responses
+// line up with the model's requests.
+func (a *Agent) callAll(ctx context.Context, calls []Call) []ToolCall {
+	d := a.newDispatcher(ctx)
+	for _, call := range calls {
+		d.dispatch(call)
+	}
+	return d.wait()
+}
+
+// dispatcher runs tool calls on a bounded pool of workers as they are
+// handed to it, which lets streamed calls start before the turn is over.
+type dispatcher struct {
+	agent   *Agent
+	ctx     context.Context
+	timeout time.Duration
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	results []ToolCall
+}
+
+func (a *Agent) newDispatcher(ctx context.Context) *dispatcher {
+	parallelism := a.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+	timeout := a.CallTimeout
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
+	}
+	return &dispatcher{
+		agent:   a,
+		ctx:     ctx,
+		timeout: timeout,
+		sem:     make(chan struct{}, parallelism),
+	}
+}
+
+// dispatch starts call in the background.
+func (d *dispatcher) dispatch(call Call) {
+	d.mu.Lock()
+	i := len(d.results)
+	d.results = append(d.results, ToolCall{Call: call})
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.sem <- struct{}{}
+		defer func() { <-d.sem }()
+
+		callCtx, cancel := context.WithTimeout(d.ctx, d.timeout)
+		defer cancel()
+
+		start := time.Now()
+		resp := d.agent.Tools.CallTool(callCtx, call.Name, call.Args)
+		duration := time.Since(start)
+
+		d.mu.Lock()
+		d.results[i].Response = resp
+		d.results[i].Duration = duration
+		result := d.results[i]
+		d.mu.Unlock()
+
+		if d.agent.OnToolCall != nil {
+			d.agent.OnToolCall(result)
+		}
+	}()
+}
+
+// wait blocks until every dispatched call has finished and returns the
+// results in dispatch order.
+func (d *dispatcher) wait() []ToolCall {
+	d.wg.Wait()
+	return d.results
+}
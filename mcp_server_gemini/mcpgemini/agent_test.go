@@ -0,0 +1,234 @@
+package mcpgemini_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+
+	"example.com/mcp-server/mcpgemini"
+	"example.com/mcp-server/mcpgemini/fake"
+)
+
+type helloArgs struct {
+	Name string `json:"name" jsonschema:"required,description=The name to say hello to"`
+}
+
+type priceArgs struct {
+	Currency string `json:"currency" jsonschema:"required,description=The currency code"`
+}
+
+// startServer serves a small MCP server with a hello and a price tool
+// over in-memory pipes and returns a bridge connected to it.
+func startServer(t *testing.T) *mcpgemini.Bridge {
+	t.Helper()
+	return connect(t, func(server *mcp_golang.Server) error {
+		err := server.RegisterTool("hello", "Say hello to a person", func(args helloArgs) (*mcp_golang.ToolResponse, error) {
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Hello %s!", args.Name))), nil
+		})
+		if err != nil {
+			return err
+		}
+		return server.RegisterTool("price", "Get the Bitcoin price", func(args priceArgs) (*mcp_golang.ToolResponse, error) {
+			if args.Currency != "usd" {
+				return nil, fmt.Errorf("no price in %s", args.Currency)
+			}
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent("The current Bitcoin price in usd is 65000.00"),
+				mcp_golang.NewTextResourceContent("prices://result/price", `{"currency":"usd","price":65000}`, mcpgemini.StructuredMIMEType),
+			), nil
+		})
+	})
+}
+
+// connect serves an MCP server with the tools added by register over
+// in-memory pipes and returns a bridge connected to it.
+func connect(t *testing.T, register func(server *mcp_golang.Server) error) *mcpgemini.Bridge {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	server := mcp_golang.NewServer(stdio.NewStdioServerTransportWithIO(serverIn, serverOut))
+	if err := register(server); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	bridge, err := mcpgemini.Connect(ctx, stdio.NewStdioServerTransportWithIO(clientIn, clientOut))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		clientOut.Close()
+		serverOut.Close()
+	})
+	return bridge
+}
+
+// newAgent returns an agent playing script against the test server.
+func newAgent(t *testing.T, script string) (*mcpgemini.Agent, *fake.Provider) {
+	t.Helper()
+	bridge := startServer(t)
+	ctx := context.Background()
+
+	s, err := fake.Parse([]byte(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := fake.New(s)
+	tools, err := bridge.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.SetTools(tools); err != nil {
+		t.Fatal(err)
+	}
+	return mcpgemini.NewAgent(provider, bridge), provider
+}
+
+func TestAgentRun(t *testing.T) {
+	agent, provider := newAgent(t, `
+turns:
+  - expect: {text: Alice}
+    text: Let me check.
+    calls:
+      - {name: hello, args: {name: Alice}}
+      - {name: price, args: {currency: usd}}
+      - {name: price, args: {currency: xyz}}
+  - expect:
+      results: [hello, price, price]
+      contains: Hello Alice!
+    text: Hello Alice, Bitcoin is at 65000 USD.
+`)
+
+	res, err := agent.Run(context.Background(), "Say hello to Alice and tell her the Bitcoin price")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Text != "Hello Alice, Bitcoin is at 65000 USD." {
+		t.Errorf("Text = %q", res.Text)
+	}
+
+	if len(res.Steps) != 1 {
+		t.Fatalf("got %d steps, want 1", len(res.Steps))
+	}
+	step := res.Steps[0]
+	if step.Text != "Let me check." || len(step.Calls) != 3 {
+		t.Fatalf("step = %+v, want the text and 3 calls", step)
+	}
+	if got := step.Calls[0].Response; got["response"] != "Hello Alice!" {
+		t.Errorf("hello response = %v, want Hello Alice! as text", got)
+	}
+	if got := step.Calls[1].Response; got["price"] != 65000.0 || got["currency"] != "usd" {
+		t.Errorf("price response = %v, want the structured result", got)
+	}
+	if got := step.Calls[2].Response; got["error"] == nil {
+		t.Errorf("failed price response = %v, want an error", got)
+	}
+
+	received := provider.Received()
+	if len(received) != 2 {
+		t.Fatalf("provider received %d messages, want 2", len(received))
+	}
+	if received[0].Text == "" || len(received[0].Results) != 0 {
+		t.Errorf("first message = %+v, want the prompt", received[0])
+	}
+	if len(received[1].Results) != 3 || received[1].Results[0].Call.Name != "hello" {
+		t.Errorf("second message = %+v, want the 3 results in call order", received[1])
+	}
+	if provider.Remaining() != 0 {
+		t.Errorf("%d turns not played", provider.Remaining())
+	}
+}
+
+func TestAgentDeclarations(t *testing.T) {
+	_, provider := newAgent(t, `turns: []`)
+
+	decls := map[string]*genai.FunctionDeclaration{}
+	for _, decl := range provider.Declarations() {
+		decls[decl.Name] = decl
+	}
+	hello := decls["hello"]
+	if hello == nil || hello.Description != "Say hello to a person" {
+		t.Fatalf("hello declaration = %+v", hello)
+	}
+	if hello.Parameters == nil || hello.Parameters.Type != genai.TypeObject {
+		t.Fatalf("hello parameters = %+v, want an object", hello.Parameters)
+	}
+	name := hello.Parameters.Properties["name"]
+	if name == nil || name.Type != genai.TypeString || name.Description != "The name to say hello to" {
+		t.Errorf("name parameter = %+v", name)
+	}
+	if len(hello.Parameters.Required) != 1 || hello.Parameters.Required[0] != "name" {
+		t.Errorf("required = %v, want [name]", hello.Parameters.Required)
+	}
+	if decls["price"] == nil {
+		t.Error("price is not declared")
+	}
+}
+
+func TestAgentMaxSteps(t *testing.T) {
+	agent, provider := newAgent(t, `
+turns:
+  - calls: [{name: hello, args: {name: A}}]
+  - expect: {results: [hello]}
+    calls: [{name: hello, args: {name: B}}]
+  - expect: {results: [hello], contains: step limit reached}
+    text: I could only greet A.
+`)
+	agent.MaxSteps = 1
+
+	res, err := agent.Run(context.Background(), "Greet A and B")
+	if !errors.Is(err, mcpgemini.ErrMaxSteps) {
+		t.Fatalf("err = %v, want ErrMaxSteps", err)
+	}
+	if len(res.Steps) != 1 {
+		t.Errorf("got %d steps, want 1", len(res.Steps))
+	}
+	if res.Text != "I could only greet A." {
+		t.Errorf("Text = %q, want the answer after the declined call", res.Text)
+	}
+
+	// The call past the limit is answered, not left pending.
+	received := provider.Received()
+	last := received[len(received)-1]
+	if len(last.Results) != 1 || last.Results[0].Response["error"] == nil {
+		t.Errorf("last message = %+v, want an error result for the declined call", last)
+	}
+	if provider.Remaining() != 0 {
+		t.Errorf("%d turns not played", provider.Remaining())
+	}
+}
+
+func TestAgentRunStreamMaxSteps(t *testing.T) {
+	agent, provider := newAgent(t, `
+turns:
+  - calls: [{name: hello, args: {name: A}}]
+  - calls: [{name: hello, args: {name: B}}]
+  - expect: {contains: step limit reached}
+    text: Done with A.
+`)
+	agent.MaxSteps = 1
+
+	var streamed string
+	res, err := agent.RunStream(context.Background(), "Greet A and B", func(text string) { streamed += text })
+	if !errors.Is(err, mcpgemini.ErrMaxSteps) {
+		t.Fatalf("err = %v, want ErrMaxSteps", err)
+	}
+	if res.Text != "Done with A." || streamed != "Done with A." {
+		t.Errorf("Text = %q, streamed %q", res.Text, streamed)
+	}
+	if provider.Remaining() != 0 {
+		t.Errorf("%d turns not played", provider.Remaining())
+	}
+}
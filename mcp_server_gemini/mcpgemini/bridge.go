@@ -0,0 +1,230 @@
+// Package mcpgemini exposes the tools of an MCP server to Gemini function
+// calling, or to any other chat model through a Provider, and routes the
+// resulting tool calls back to the server.
+package mcpgemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+)
+
+// Bridge converts the tools of a single MCP client into Gemini tools and
+// executes Gemini function calls against it. Bridges are created by
+// Connect, which sets up the side channel some requests go through.
+type Bridge struct {
+	client *mcp_golang.Client
+	rpc    *rpcTap
+}
+
+// Client returns the underlying MCP client.
+func (b *Bridge) Client() *mcp_golang.Client {
+	return b.client
+}
+
+// ListTools returns every tool on the MCP server, following pagination.
+func (b *Bridge) ListTools(ctx context.Context) ([]mcp_golang.ToolRetType, error) {
+	var all []mcp_golang.ToolRetType
+	var cursor *string
+	for {
+		tools, err := b.client.ListTools(ctx, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		all = append(all, tools.Tools...)
+
+		if tools.NextCursor == nil {
+			return all, nil
+		}
+		cursor = tools.NextCursor
+	}
+}
+
+// Tools lists every tool on the MCP server and converts it into a Gemini
+// function declaration. Tools whose schema cannot be converted are skipped
+// and logged rather than failing the whole list.
+func (b *Bridge) Tools(ctx context.Context) ([]*genai.Tool, error) {
+	tools, err := b.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return GeminiTools(tools), nil
+}
+
+// GeminiTools converts MCP tools into Gemini tools, skipping and logging
+// those whose schema cannot be converted.
+func GeminiTools(tools []mcp_golang.ToolRetType) []*genai.Tool {
+	geminiTools := []*genai.Tool{}
+	for _, tool := range tools {
+		decl, err := Declaration(tool)
+		if err != nil {
+			log.Printf("skipping tool %s: %s", tool.Name, err)
+			continue
+		}
+		geminiTools = append(geminiTools, &genai.Tool{
+			FunctionDeclarations: []*genai.FunctionDeclaration{decl},
+		})
+	}
+	return geminiTools
+}
+
+// Declaration converts a single MCP tool into a Gemini function declaration.
+func Declaration(tool mcp_golang.ToolRetType) (*genai.FunctionDeclaration, error) {
+	desc := ""
+	if tool.Description != nil {
+		desc = *tool.Description
+	}
+
+	params, warnings, err := ConvertInputSchema(tool.InputSchema)
+	for _, warning := range warnings {
+		log.Printf("warning: tool %s schema %s", tool.Name, warning)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error with converting tool.InputSchema - %w", err)
+	}
+
+	return &genai.FunctionDeclaration{
+		Name:        tool.Name,
+		Description: desc,
+		Parameters:  params,
+	}, nil
+}
+
+// Call executes a Gemini function call on the MCP server.
+func (b *Bridge) Call(ctx context.Context, call genai.FunctionCall) genai.FunctionResponse {
+	return genai.FunctionResponse{
+		Name:     call.Name,
+		Response: b.CallTool(ctx, call.Name, call.Args),
+	}
+}
+
+// StructuredMIMEType is the MIME type of the embedded resource in which a
+// tool returns its result as a JSON object, next to the text for people.
+const StructuredMIMEType = "application/json"
+
+// CallTool calls the named tool on the MCP server and returns the response
+// to hand back to the model. Failures are reported to the model inside the
+// response rather than returned, so the conversation can carry on.
+//
+// A tool that returns a JSON object in a StructuredMIMEType resource has
+// that object handed back as is, so the model reads fields rather than
+// prose. Other tools have their text wrapped as {"response": text}.
+func (b *Bridge) CallTool(ctx context.Context, name string, args map[string]any) map[string]any {
+	// mcp-golang's Client.CallTool fails on any content but text, so the
+	// request goes over the side channel like ReadResource.
+	if args == nil {
+		args = map[string]any{}
+	}
+	raw, err := b.rpc.request(ctx, "tools/call", map[string]any{"name": name, "arguments": args})
+	if err != nil {
+		log.Printf("failed to call tool %s: %v", name, err)
+		return map[string]any{"error": err.Error()}
+	}
+	resp, err := toolResult(raw)
+	if err != nil {
+		log.Printf("failed to decode result of tool %s: %v", name, err)
+		return map[string]any{"error": err.Error()}
+	}
+	return resp
+}
+
+// toolResult converts the raw result of tools/call into the response for
+// the model.
+func toolResult(raw json.RawMessage) (map[string]any, error) {
+	type resource struct {
+		URI      string `json:"uri"`
+		MIMEType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			// mcp-golang servers flatten embedded resources into the
+			// content rather than nest them under resource.
+			resource
+			Resource *resource `json:"resource"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	var structured map[string]any
+	for _, c := range result.Content {
+		switch c.Type {
+		case "text":
+			texts = append(texts, c.Text)
+		case "resource":
+			res := c.resource
+			if c.Resource != nil {
+				res = *c.Resource
+			}
+			if res.MIMEType != StructuredMIMEType || structured != nil {
+				continue
+			}
+			if err := json.Unmarshal([]byte(res.Text), &structured); err != nil {
+				return nil, fmt.Errorf("invalid structured content %s: %w", res.URI, err)
+			}
+		}
+	}
+	text := strings.Join(texts, "\n")
+
+	switch {
+	case result.IsError:
+		return map[string]any{"error": text}, nil
+	case structured != nil:
+		return structured, nil
+	default:
+		return map[string]any{"response": text}, nil
+	}
+}
+
+// StdioTransport starts cmd and returns a transport over its stdin and
+// stdout, for callers that wrap the transport before connecting.
+func StdioTransport(cmd *exec.Cmd) (transport.Transport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start server: %w", err)
+	}
+	return stdio.NewStdioServerTransportWithIO(stdout, stdin), nil
+}
+
+// Connect initialises a client over t and returns a bridge for it.
+func Connect(ctx context.Context, t transport.Transport) (*Bridge, error) {
+	rpc := newRPCTap(t)
+	client := mcp_golang.NewClient(rpc)
+	if _, err := client.Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize client: %w", err)
+	}
+	return &Bridge{client: client, rpc: rpc}, nil
+}
+
+// PrintResponse prints every part of every candidate in resp.
+func PrintResponse(resp *genai.GenerateContentResponse) {
+	for _, cand := range resp.Candidates {
+		if cand.Content != nil {
+			for _, part := range cand.Content.Parts {
+				fmt.Println(part)
+			}
+		}
+	}
+	fmt.Println("---")
+}
@@ -0,0 +1,72 @@
+package mcpgemini_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+func TestBridgeFailsCallsWhenServerDies(t *testing.T) {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	called := make(chan struct{})
+	unblock := make(chan struct{})
+	defer close(unblock)
+	server := mcp_golang.NewServer(stdio.NewStdioServerTransportWithIO(serverIn, serverOut))
+	err := server.RegisterTool("wait", "Never answer", func(args helloArgs) (*mcp_golang.ToolResponse, error) {
+		close(called)
+		<-unblock
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent("too late")), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client := stdio.NewStdioServerTransportWithIO(clientIn, clientOut)
+	bridge, err := mcpgemini.Connect(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan map[string]any, 1)
+	go func() {
+		done <- bridge.CallTool(context.Background(), "wait", map[string]any{"name": "Alice"})
+	}()
+	<-called
+
+	// The server dies in the middle of the call, and the transport is
+	// closed as the Supervisor does when the process exits.
+	serverOut.Close()
+	serverIn.Close()
+	client.Close()
+
+	select {
+	case res := <-done:
+		if msg, _ := res["error"].(string); msg != mcpgemini.ErrConnectionClosed.Error() {
+			t.Errorf("CallTool = %v, want the closed connection", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("CallTool still waiting after the server died")
+	}
+
+	if res := bridge.CallTool(context.Background(), "wait", nil); !strings.Contains(res["error"].(string), "closed") {
+		t.Errorf("CallTool after close = %v, want the closed connection", res)
+	}
+	if _, err := bridge.ReadResource(context.Background(), "prices://currencies"); !errors.Is(err, mcpgemini.ErrConnectionClosed) {
+		t.Errorf("ReadResource after close = %v, want ErrConnectionClosed", err)
+	}
+}
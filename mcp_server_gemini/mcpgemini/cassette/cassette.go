@@ -0,0 +1,131 @@
+// Package cassette records the traffic of an agent run, both the model
+// turns of a Provider and the JSON-RPC exchanges with the MCP server, and
+// replays it later without the model or the server. A replay fails as
+// soon as the agent sends something the recording does not contain, so a
+// cassette taken from a real session works as a regression test when
+// prompts or tool schemas change.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+// ErrMismatch is returned when a replayed run diverges from the recording.
+var ErrMismatch = errors.New("cassette: request does not match the recording")
+
+// Cassette holds a recorded run. It is safe for concurrent use.
+type Cassette struct {
+	// Tools are the tools declared to the model.
+	Tools []mcp_golang.ToolRetType `json:"tools,omitempty"`
+	// Turns are the model turns, in order.
+	Turns []Turn `json:"turns"`
+	// Exchanges are the MCP requests and their responses, in the order
+	// the responses arrived.
+	Exchanges []Exchange `json:"exchanges"`
+
+	mu sync.Mutex
+}
+
+// Turn is a message sent to the model and its reply.
+type Turn struct {
+	Message mcpgemini.Message `json:"message"`
+	Reply   *mcpgemini.Reply  `json:"reply,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// Exchange is an MCP request and the result or error the server answered.
+type Exchange struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ExchangeError  `json:"error,omitempty"`
+}
+
+// ExchangeError is a JSON-RPC error answered by the server.
+type ExchangeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// New returns an empty cassette to record into.
+func New() *Cassette {
+	return &Cassette{}
+}
+
+// Load reads a cassette file for replay.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cassette: %w", err)
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("error parsing cassette %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the cassette to path, replacing it atomically.
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error encoding cassette: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cassette-*")
+	if err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	return nil
+}
+
+// canonical re-encodes v so that equal values compare equal regardless
+// of key order or number formatting.
+func canonical(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// sameJSON reports whether a and b encode to the same canonical JSON.
+func sameJSON(a, b any) bool {
+	ca, err := canonical(a)
+	if err != nil {
+		return false
+	}
+	cb, err := canonical(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ca, cb)
+}
@@ -0,0 +1,157 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+
+	"example.com/mcp-server/mcpgemini"
+	"example.com/mcp-server/mcpgemini/cassette"
+	"example.com/mcp-server/mcpgemini/fake"
+)
+
+type priceArgs struct {
+	Currency string `json:"currency" jsonschema:"required,description=The currency code"`
+}
+
+const script = `
+turns:
+  - expect: {text: Bitcoin}
+    calls:
+      - {name: price, args: {currency: usd}}
+  - expect: {results: [price], contains: "65000"}
+    text: Bitcoin is at 65000 USD.
+`
+
+const question = "What is the Bitcoin price?"
+
+// serverTransport serves a price tool over in-memory pipes and returns the
+// client end of the connection.
+func serverTransport(t *testing.T) transport.Transport {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	server := mcp_golang.NewServer(stdio.NewStdioServerTransportWithIO(serverIn, serverOut))
+	err := server.RegisterTool("price", "Get the Bitcoin price", func(args priceArgs) (*mcp_golang.ToolResponse, error) {
+		if args.Currency != "usd" {
+			return nil, fmt.Errorf("no price in %s", args.Currency)
+		}
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent("The current Bitcoin price in usd is 65000.00")), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		clientOut.Close()
+		serverOut.Close()
+	})
+	return stdio.NewStdioServerTransportWithIO(clientIn, clientOut)
+}
+
+// run connects to t, declares the tools to provider and asks the question.
+func run(t *testing.T, tr transport.Transport, provider mcpgemini.Provider) (*mcpgemini.Result, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bridge, err := mcpgemini.Connect(ctx, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := bridge.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.SetTools(tools); err != nil {
+		return nil, err
+	}
+	return mcpgemini.NewAgent(provider, bridge).Run(ctx, question)
+}
+
+// record runs the script against the server and returns the cassette as
+// saved to and loaded from disk.
+func record(t *testing.T) *cassette.Cassette {
+	t.Helper()
+	s, err := fake.Parse([]byte(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tape := cassette.New()
+	res, err := run(t, tape.RecordTransport(serverTransport(t)), tape.Recorder(fake.New(s)))
+	if err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	if res.Text != "Bitcoin is at 65000 USD." {
+		t.Fatalf("recorded answer = %q", res.Text)
+	}
+
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := tape.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func TestReplay(t *testing.T) {
+	tape := record(t)
+	if len(tape.Turns) != 2 {
+		t.Fatalf("recorded %d turns, want 2", len(tape.Turns))
+	}
+
+	player := tape.Player()
+	res, err := run(t, tape.ReplayTransport(), player)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if res.Text != "Bitcoin is at 65000 USD." {
+		t.Errorf("replayed answer = %q", res.Text)
+	}
+	if len(res.Steps) != 1 || res.Steps[0].Calls[0].Response["response"] != "The current Bitcoin price in usd is 65000.00" {
+		t.Errorf("steps = %+v, want the recorded tool result", res.Steps)
+	}
+	if n := player.Remaining(); n != 0 {
+		t.Errorf("%d turns not replayed", n)
+	}
+}
+
+func TestReplayChangedArgument(t *testing.T) {
+	tape := record(t)
+	tape.Turns[0].Reply.Calls[0].Args["currency"] = "eur"
+
+	_, err := run(t, tape.ReplayTransport(), tape.Player())
+	if !errors.Is(err, cassette.ErrMismatch) {
+		t.Fatalf("err = %v, want a mismatch", err)
+	}
+	for _, want := range []string{"turn 2", `"args":{"currency":"eur"}`, "does not match the recording: tools/call"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %q, want it to name %s", err, want)
+		}
+	}
+}
+
+func TestReplayChangedQuestion(t *testing.T) {
+	tape := record(t)
+	tape.Turns[0].Message.Text = "What is the Ether price?"
+
+	_, err := run(t, tape.ReplayTransport(), tape.Player())
+	if !errors.Is(err, cassette.ErrMismatch) || !strings.Contains(err.Error(), "turn 1: recorded") {
+		t.Fatalf("err = %v, want a mismatch of the first turn", err)
+	}
+}
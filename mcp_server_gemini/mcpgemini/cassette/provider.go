@@ -0,0 +1,154 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+// Recorder is a Provider that forwards to another Provider and records
+// every turn into a cassette. It streams when the other Provider does,
+// and records the whole reply once the stream is over.
+type Recorder struct {
+	Provider mcpgemini.Provider
+	cassette *Cassette
+}
+
+// Recorder returns a Recorder around provider.
+func (c *Cassette) Recorder(provider mcpgemini.Provider) *Recorder {
+	return &Recorder{Provider: provider, cassette: c}
+}
+
+func (r *Recorder) SetTools(tools []mcp_golang.ToolRetType) error {
+	r.cassette.mu.Lock()
+	r.cassette.Tools = tools
+	r.cassette.mu.Unlock()
+	return r.Provider.SetTools(tools)
+}
+
+func (r *Recorder) Send(ctx context.Context, msg mcpgemini.Message) (*mcpgemini.Reply, error) {
+	reply, err := r.Provider.Send(ctx, msg)
+	r.record(msg, reply, err)
+	return reply, err
+}
+
+// SendStream streams msg through the other Provider, or sends it whole
+// and replays the reply to the callbacks if it cannot stream.
+func (r *Recorder) SendStream(ctx context.Context, msg mcpgemini.Message, onText func(string), onCall func(mcpgemini.Call)) (*mcpgemini.Reply, error) {
+	if sp, ok := r.Provider.(mcpgemini.StreamProvider); ok {
+		reply, err := sp.SendStream(ctx, msg, onText, onCall)
+		r.record(msg, reply, err)
+		return reply, err
+	}
+
+	reply, err := r.Send(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if onText != nil && reply.Text != "" {
+		onText(reply.Text)
+	}
+	if onCall != nil {
+		for _, call := range reply.Calls {
+			onCall(call)
+		}
+	}
+	return reply, nil
+}
+
+// record appends a turn to the cassette.
+func (r *Recorder) record(msg mcpgemini.Message, reply *mcpgemini.Reply, err error) {
+	turn := Turn{Message: msg, Reply: reply}
+	if err != nil {
+		turn.Reply = nil
+		turn.Error = err.Error()
+	}
+	r.cassette.mu.Lock()
+	r.cassette.Turns = append(r.cassette.Turns, turn)
+	r.cassette.mu.Unlock()
+}
+
+func (r *Recorder) Reset() {
+	r.Provider.Reset()
+}
+
+// Player is a Provider that answers with the recorded turns of a
+// cassette, in order, after checking that each message matches.
+type Player struct {
+	cassette *Cassette
+	next     int
+}
+
+// Player returns a Player starting at the first recorded turn.
+func (c *Cassette) Player() *Player {
+	return &Player{cassette: c}
+}
+
+// SetTools fails with ErrMismatch if the tools differ from the recorded
+// ones, since the recorded replies may no longer be valid for them.
+func (p *Player) SetTools(tools []mcp_golang.ToolRetType) error {
+	p.cassette.mu.Lock()
+	defer p.cassette.mu.Unlock()
+
+	recorded := map[string]mcp_golang.ToolRetType{}
+	for _, tool := range p.cassette.Tools {
+		recorded[tool.Name] = tool
+	}
+	for _, tool := range tools {
+		old, ok := recorded[tool.Name]
+		if !ok {
+			return fmt.Errorf("%w: tool %s was not recorded", ErrMismatch, tool.Name)
+		}
+		if !sameJSON(old, tool) {
+			return fmt.Errorf("%w: schema of tool %s changed", ErrMismatch, tool.Name)
+		}
+		delete(recorded, tool.Name)
+	}
+	for name := range recorded {
+		return fmt.Errorf("%w: tool %s is missing", ErrMismatch, name)
+	}
+	return nil
+}
+
+func (p *Player) Send(ctx context.Context, msg mcpgemini.Message) (*mcpgemini.Reply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.cassette.mu.Lock()
+	defer p.cassette.mu.Unlock()
+
+	if p.next >= len(p.cassette.Turns) {
+		return nil, fmt.Errorf("%w: turn %d was not recorded", ErrMismatch, p.next+1)
+	}
+	turn := p.cassette.Turns[p.next]
+	p.next++
+
+	if !sameJSON(turn.Message, msg) {
+		want, _ := canonical(turn.Message)
+		got, _ := canonical(msg)
+		return nil, fmt.Errorf("%w: turn %d: recorded %s, got %s", ErrMismatch, p.next, want, got)
+	}
+	if turn.Error != "" {
+		return nil, errors.New(turn.Error)
+	}
+	return turn.Reply, nil
+}
+
+// Reset rewinds the player to the first recorded turn.
+func (p *Player) Reset() {
+	p.cassette.mu.Lock()
+	p.next = 0
+	p.cassette.mu.Unlock()
+}
+
+// Remaining returns the number of recorded turns not replayed yet.
+func (p *Player) Remaining() int {
+	p.cassette.mu.Lock()
+	defer p.cassette.mu.Unlock()
+	return len(p.cassette.Turns) - p.next
+}
@@ -0,0 +1,184 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// recordTransport wraps an MCP transport and records every request with
+// the response the server gave to it. Notifications are passed through
+// without being recorded.
+type recordTransport struct {
+	transport.Transport
+	cassette *Cassette
+
+	mu      sync.Mutex
+	pending map[transport.RequestId]*transport.BaseJSONRPCRequest
+}
+
+// RecordTransport returns a transport that records the requests sent over
+// t and their responses into the cassette.
+func (c *Cassette) RecordTransport(t transport.Transport) transport.Transport {
+	return &recordTransport{
+		Transport: t,
+		cassette:  c,
+		pending:   map[transport.RequestId]*transport.BaseJSONRPCRequest{},
+	}
+}
+
+func (t *recordTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	if message.Type == transport.BaseMessageTypeJSONRPCRequestType {
+		t.mu.Lock()
+		t.pending[message.JsonRpcRequest.Id] = message.JsonRpcRequest
+		t.mu.Unlock()
+	}
+	return t.Transport.Send(ctx, message)
+}
+
+func (t *recordTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		t.record(message)
+		handler(ctx, message)
+	})
+}
+
+// record stores message if it answers a pending request.
+func (t *recordTransport) record(message *transport.BaseJsonRpcMessage) {
+	var id transport.RequestId
+	switch message.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		id = message.JsonRpcResponse.Id
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		id = message.JsonRpcError.Id
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	req, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	exchange := Exchange{Method: req.Method, Params: req.Params}
+	if message.Type == transport.BaseMessageTypeJSONRPCResponseType {
+		exchange.Result = message.JsonRpcResponse.Result
+	} else {
+		e := message.JsonRpcError.Error
+		exchange.Error = &ExchangeError{Code: e.Code, Message: e.Message, Data: e.Data}
+	}
+
+	t.cassette.mu.Lock()
+	t.cassette.Exchanges = append(t.cassette.Exchanges, exchange)
+	t.cassette.mu.Unlock()
+}
+
+// replayTransport answers requests from the recorded exchanges instead of
+// a server. Each request is matched to the first unused exchange with the
+// same method and parameters, so concurrent tool calls replay correctly
+// whatever order they are sent in.
+type replayTransport struct {
+	cassette *Cassette
+
+	mu      sync.Mutex
+	used    []bool
+	handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	onClose func()
+}
+
+// ReplayTransport returns a transport serving the recorded exchanges.
+// Requests without a matching exchange are answered with a JSON-RPC
+// error describing the mismatch.
+func (c *Cassette) ReplayTransport() transport.Transport {
+	return &replayTransport{cassette: c, used: make([]bool, len(c.Exchanges))}
+}
+
+func (t *replayTransport) Start(ctx context.Context) error {
+	return nil
+}
+
+func (t *replayTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	if message.Type != transport.BaseMessageTypeJSONRPCRequestType {
+		return nil
+	}
+	req := message.JsonRpcRequest
+
+	reply := t.answer(req)
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
+	if handler != nil {
+		// The protocol waits for the response after Send returns.
+		go handler(ctx, reply)
+	}
+	return nil
+}
+
+// answer builds the response to req from the first matching exchange.
+func (t *replayTransport) answer(req *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage {
+	params := json.RawMessage(req.Params)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, exchange := range t.cassette.Exchanges {
+		if t.used[i] || exchange.Method != req.Method || !sameJSON(exchange.Params, params) {
+			continue
+		}
+		t.used[i] = true
+		if exchange.Error != nil {
+			return transport.NewBaseMessageError(&transport.BaseJSONRPCError{
+				Id:      req.Id,
+				Jsonrpc: "2.0",
+				Error: transport.BaseJSONRPCErrorInner{
+					Code:    exchange.Error.Code,
+					Message: exchange.Error.Message,
+					Data:    exchange.Error.Data,
+				},
+			})
+		}
+		return transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{
+			Id:      req.Id,
+			Jsonrpc: "2.0",
+			Result:  exchange.Result,
+		})
+	}
+
+	return transport.NewBaseMessageError(&transport.BaseJSONRPCError{
+		Id:      req.Id,
+		Jsonrpc: "2.0",
+		Error: transport.BaseJSONRPCErrorInner{
+			Code:    -32603,
+			Message: fmt.Sprintf("%s: %s %s", ErrMismatch, req.Method, params),
+		},
+	})
+}
+
+func (t *replayTransport) Close() error {
+	t.mu.Lock()
+	onClose := t.onClose
+	t.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+	return nil
+}
+
+func (t *replayTransport) SetCloseHandler(handler func()) {
+	t.mu.Lock()
+	t.onClose = handler
+	t.mu.Unlock()
+}
+
+func (t *replayTransport) SetErrorHandler(handler func(error)) {}
+
+func (t *replayTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.mu.Lock()
+	t.handler = handler
+	t.mu.Unlock()
+}
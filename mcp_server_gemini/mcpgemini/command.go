@@ -0,0 +1,54 @@
+package mcpgemini
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ServerCommandEnv names the environment variable holding the command
+// that starts the MCP server, used when no command is given explicitly.
+const ServerCommandEnv = "MCP_SERVER_COMMAND"
+
+// DefaultServerBinary is where the build subcommand of the client puts
+// the bundled server, relative to the client binary or the current
+// directory.
+const DefaultServerBinary = "bin/server"
+
+// ParseCommand splits a command line on whitespace into a ServerConfig.
+// Quoting is not supported; use a host config file for arguments
+// containing spaces.
+func ParseCommand(line string) (ServerConfig, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ServerConfig{}, fmt.Errorf("empty server command")
+	}
+	return ServerConfig{Command: fields[0], Args: fields[1:]}, nil
+}
+
+// ResolveServer picks the command starting the bundled server: command if
+// set, then $MCP_SERVER_COMMAND, then a prebuilt DefaultServerBinary next
+// to the running executable or in the current directory, and finally
+// `go run ./server`, which needs the Go toolchain and the source tree.
+func ResolveServer(command string) (ServerConfig, error) {
+	if command != "" {
+		return ParseCommand(command)
+	}
+	if env := os.Getenv(ServerCommandEnv); env != "" {
+		return ParseCommand(env)
+	}
+
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), "server"))
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), DefaultServerBinary))
+	}
+	candidates = append(candidates, DefaultServerBinary)
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return ServerConfig{Command: path}, nil
+		}
+	}
+	return ServerConfig{Command: "go", Args: []string{"run", "./server"}}, nil
+}
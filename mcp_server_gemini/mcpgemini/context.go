@@ -0,0 +1,205 @@
+package mcpgemini
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+const summaryPrompt = "Summarise the following conversation between a user and an assistant. " +
+	"Keep every fact, number and tool result that may matter later. Reply with the summary only.\n\n"
+
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// HistoryManager keeps the history of a chat session within a token
+// budget by dropping the oldest exchanges, optionally replacing them with
+// a summary generated by the model.
+type HistoryManager struct {
+	Model *genai.GenerativeModel
+	// TokenBudget is the maximum number of tokens the history may use.
+	TokenBudget int32
+	// Summarize replaces dropped exchanges with a model generated summary
+	// instead of discarding them.
+	Summarize bool
+	// KeepRecent is the number of most recent exchanges that are never
+	// dropped.
+	KeepRecent int
+
+	// countTokens and generate replace the calls to the model in tests.
+	countTokens func(ctx context.Context, model *genai.GenerativeModel, parts []genai.Part) (int32, error)
+	generate    func(ctx context.Context, model *genai.GenerativeModel, prompt string) (string, error)
+}
+
+// TrimResult describes what a call to Fit did.
+type TrimResult struct {
+	TokensBefore int32
+	TokensAfter  int32
+	Dropped      int
+	Summarized   bool
+}
+
+// NewHistoryManager returns a HistoryManager for model with the given
+// budget that always keeps the latest exchange.
+func NewHistoryManager(model *genai.GenerativeModel, budget int32) *HistoryManager {
+	return &HistoryManager{
+		Model:       model,
+		TokenBudget: budget,
+		KeepRecent:  1,
+	}
+}
+
+// Fit trims session.History until it fits in the token budget.
+func (m *HistoryManager) Fit(ctx context.Context, session *genai.ChatSession) (*TrimResult, error) {
+	res := &TrimResult{}
+	if len(session.History) == 0 {
+		return res, nil
+	}
+
+	total, err := m.count(ctx, m.Model, session.History)
+	if err != nil {
+		return nil, err
+	}
+	res.TokensBefore, res.TokensAfter = total, total
+	if total <= m.TokenBudget {
+		return res, nil
+	}
+
+	// Count exchanges without tools or system instructions so that only
+	// their own tokens are subtracted from the total.
+	counter := *m.Model
+	counter.Tools = nil
+	counter.SystemInstruction = nil
+
+	groups := exchanges(session.History)
+	keep := max(m.KeepRecent, 1)
+	var dropped []*genai.Content
+	for total > m.TokenBudget && len(groups) > keep {
+		tokens, err := m.count(ctx, &counter, groups[0])
+		if err != nil {
+			return nil, err
+		}
+		total -= tokens
+		dropped = append(dropped, groups[0]...)
+		groups = groups[1:]
+		res.Dropped++
+	}
+	if res.Dropped == 0 {
+		return res, nil
+	}
+
+	var history []*genai.Content
+	if m.Summarize {
+		summary, err := m.summarize(ctx, &counter, dropped)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, summary...)
+		res.Summarized = true
+	}
+	for _, group := range groups {
+		history = append(history, group...)
+	}
+	session.History = history
+
+	res.TokensAfter, err = m.count(ctx, m.Model, session.History)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *HistoryManager) count(ctx context.Context, model *genai.GenerativeModel, contents []*genai.Content) (int32, error) {
+	var parts []genai.Part
+	for _, c := range contents {
+		parts = append(parts, c.Parts...)
+	}
+	if len(parts) == 0 {
+		return 0, nil
+	}
+	if m.countTokens != nil {
+		return m.countTokens(ctx, model, parts)
+	}
+	resp, err := model.CountTokens(ctx, parts...)
+	if err != nil {
+		return 0, fmt.Errorf("model.CountTokens: %w", err)
+	}
+	return resp.TotalTokens, nil
+}
+
+// summarize asks the model to summarise contents and returns the summary
+// as a user turn acknowledged by the model, keeping roles alternating.
+func (m *HistoryManager) summarize(ctx context.Context, model *genai.GenerativeModel, contents []*genai.Content) ([]*genai.Content, error) {
+	var transcript strings.Builder
+	for _, c := range contents {
+		for _, part := range c.Parts {
+			text := DescribePart(part)
+			// Fold earlier summaries into the new one
+			text = strings.TrimPrefix(text, summaryPrefix)
+			fmt.Fprintf(&transcript, "%s: %s\n", c.Role, text)
+		}
+	}
+
+	generate := m.generate
+	if generate == nil {
+		generate = generateText
+	}
+	summary, err := generate(ctx, model, summaryPrompt+transcript.String())
+	if err != nil {
+		return nil, fmt.Errorf("error summarising history: %w", err)
+	}
+
+	return []*genai.Content{
+		{Role: "user", Parts: []genai.Part{genai.Text(summaryPrefix + summary)}},
+		{Role: "model", Parts: []genai.Part{genai.Text("Understood.")}},
+	}, nil
+}
+
+func generateText(ctx context.Context, model *genai.GenerativeModel, prompt string) (string, error) {
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+	text, _, err := splitResponse(resp)
+	return text, err
+}
+
+// exchanges splits history into groups that each start with a user text
+// turn. Function responses never start a group, so a FunctionCall always
+// stays together with its FunctionResponse.
+func exchanges(history []*genai.Content) [][]*genai.Content {
+	var groups [][]*genai.Content
+	for _, c := range history {
+		if len(groups) == 0 || (c.Role == "user" && hasText(c)) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], c)
+	}
+	return groups
+}
+
+func hasText(c *genai.Content) bool {
+	for _, part := range c.Parts {
+		if _, ok := part.(genai.Text); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribePart renders a content part as a single line of text.
+func DescribePart(part genai.Part) string {
+	switch p := part.(type) {
+	case genai.Text:
+		return string(p)
+	case genai.FunctionCall:
+		return fmt.Sprintf("called %s(%v)", p.Name, p.Args)
+	case genai.FunctionResponse:
+		return fmt.Sprintf("%s returned %v", p.Name, p.Response)
+	case genai.Blob:
+		return fmt.Sprintf("[%s, %d bytes]", p.MIMEType, len(p.Data))
+	default:
+		return fmt.Sprintf("[%T]", part)
+	}
+}
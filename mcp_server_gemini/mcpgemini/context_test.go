@@ -0,0 +1,201 @@
+package mcpgemini
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// chatHistory is three exchanges, the first and last with a tool call,
+// of 4, 2 and 4 contents.
+func chatHistory() []*genai.Content {
+	call := func(n string) []*genai.Content {
+		return []*genai.Content{
+			{Role: "model", Parts: []genai.Part{genai.FunctionCall{Name: "price", Args: map[string]any{"n": n}}}},
+			{Role: "user", Parts: []genai.Part{genai.FunctionResponse{Name: "price", Response: map[string]any{"n": n}}}},
+		}
+	}
+	var history []*genai.Content
+	history = append(history, userText("q1"))
+	history = append(history, call("1")...)
+	history = append(history, modelText("a1"), userText("q2"), modelText("a2"), userText("q3"))
+	history = append(history, call("3")...)
+	return append(history, modelText("a3"))
+}
+
+func userText(s string) *genai.Content {
+	return &genai.Content{Role: "user", Parts: []genai.Part{genai.Text(s)}}
+}
+
+func modelText(s string) *genai.Content {
+	return &genai.Content{Role: "model", Parts: []genai.Part{genai.Text(s)}}
+}
+
+// describe renders contents as "role: part" lines for comparison.
+func describe(contents []*genai.Content) []string {
+	var lines []string
+	for _, c := range contents {
+		for _, part := range c.Parts {
+			lines = append(lines, c.Role+": "+DescribePart(part))
+		}
+	}
+	return lines
+}
+
+// tenTokensPerPart counts every part as 10 tokens.
+func tenTokensPerPart(ctx context.Context, model *genai.GenerativeModel, parts []genai.Part) (int32, error) {
+	return int32(10 * len(parts)), nil
+}
+
+func TestExchanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []*genai.Content
+		want    []int
+	}{
+		{name: "empty"},
+		{name: "tool calls stay with their exchange", history: chatHistory(), want: []int{4, 2, 4}},
+		{
+			name:    "leading model turn forms its own exchange",
+			history: []*genai.Content{modelText("hello"), userText("q1"), modelText("a1")},
+			want:    []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, group := range exchanges(tt.history) {
+				got = append(got, len(group))
+				if _, ok := group[len(group)-1].Parts[0].(genai.FunctionCall); ok {
+					t.Errorf("exchange ends with a function call: %q", describe(group))
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("exchange sizes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryManagerFit(t *testing.T) {
+	tests := []struct {
+		name       string
+		budget     int32
+		keepRecent int
+		summarize  bool
+		want       TrimResult
+		// first is the first remaining content.
+		first []string
+	}{
+		{
+			name:   "fits",
+			budget: 100,
+			want:   TrimResult{TokensBefore: 100, TokensAfter: 100},
+			first:  []string{"user: q1"},
+		},
+		{
+			name:   "drops the oldest exchange",
+			budget: 60,
+			want:   TrimResult{TokensBefore: 100, TokensAfter: 60, Dropped: 1},
+			first:  []string{"user: q2"},
+		},
+		{
+			name:   "keeps the latest exchange over budget",
+			budget: 10,
+			want:   TrimResult{TokensBefore: 100, TokensAfter: 40, Dropped: 2},
+			first:  []string{"user: q3"},
+		},
+		{
+			name:       "keeps recent exchanges",
+			budget:     10,
+			keepRecent: 2,
+			want:       TrimResult{TokensBefore: 100, TokensAfter: 60, Dropped: 1},
+			first:      []string{"user: q2"},
+		},
+		{
+			name:      "summarizes dropped exchanges",
+			budget:    60,
+			summarize: true,
+			want:      TrimResult{TokensBefore: 100, TokensAfter: 80, Dropped: 1, Summarized: true},
+			first:     []string{"user: " + summaryPrefix + "q1 was asked", "model: Understood.", "user: q2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			m := &HistoryManager{
+				Model:       &genai.GenerativeModel{},
+				TokenBudget: tt.budget,
+				Summarize:   tt.summarize,
+				KeepRecent:  tt.keepRecent,
+				countTokens: tenTokensPerPart,
+				generate: func(ctx context.Context, model *genai.GenerativeModel, p string) (string, error) {
+					prompt = p
+					return "q1 was asked", nil
+				},
+			}
+			session := &genai.ChatSession{History: chatHistory()}
+
+			res, err := m.Fit(context.Background(), session)
+			if err != nil {
+				t.Fatalf("Fit: %v", err)
+			}
+			if *res != tt.want {
+				t.Errorf("Fit = %+v, want %+v", *res, tt.want)
+			}
+			got := describe(session.History)
+			if len(got) < len(tt.first) || !slices.Equal(got[:len(tt.first)], tt.first) {
+				t.Errorf("history = %q, want it to start with %q", got, tt.first)
+			}
+			if got[len(got)-1] != "model: a3" {
+				t.Errorf("history ends with %q, want the latest answer", got[len(got)-1])
+			}
+
+			if tt.summarize {
+				if !strings.Contains(prompt, "user: q1\nmodel: called price(map[n:1])\nuser: price returned map[n:1]\nmodel: a1\n") {
+					t.Errorf("summary prompt = %q, want the dropped exchange", prompt)
+				}
+				if strings.Contains(prompt, "q2") {
+					t.Errorf("summary prompt = %q, want only the dropped exchange", prompt)
+				}
+			} else if prompt != "" {
+				t.Errorf("summarized without Summarize: %q", prompt)
+			}
+		})
+	}
+}
+
+func TestHistoryManagerFitFoldsSummaries(t *testing.T) {
+	var prompt string
+	m := &HistoryManager{
+		Model:       &genai.GenerativeModel{},
+		TokenBudget: 30,
+		Summarize:   true,
+		KeepRecent:  1,
+		countTokens: tenTokensPerPart,
+		generate: func(ctx context.Context, model *genai.GenerativeModel, p string) (string, error) {
+			prompt = p
+			return "new summary", nil
+		},
+	}
+	session := &genai.ChatSession{History: []*genai.Content{
+		userText(summaryPrefix + "old summary"),
+		modelText("Understood."),
+		userText("q2"),
+		modelText("a2"),
+	}}
+
+	if _, err := m.Fit(context.Background(), session); err != nil {
+		t.Fatalf("Fit: %v", err)
+	}
+	if !strings.Contains(prompt, "user: old summary\n") || strings.Count(prompt, summaryPrefix) != 0 {
+		t.Errorf("summary prompt = %q, want the earlier summary without its prefix", prompt)
+	}
+	want := []string{"user: " + summaryPrefix + "new summary", "model: Understood.", "user: q2", "model: a2"}
+	if got := describe(session.History); !slices.Equal(got, want) {
+		t.Errorf("history = %q, want %q", got, want)
+	}
+}
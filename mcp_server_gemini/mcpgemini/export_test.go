@@ -0,0 +1,17 @@
+package mcpgemini
+
+import "sort"
+
+// NewTestHost returns a Host over bridges that are already connected.
+func NewTestHost(bridges map[string]*Bridge) *Host {
+	h := &Host{
+		servers: map[string]hostServer{},
+		routes:  map[string]route{},
+	}
+	for name, bridge := range bridges {
+		h.names = append(h.names, name)
+		h.servers[name] = &remoteServer{Bridge: bridge, transport: bridge.rpc}
+	}
+	sort.Strings(h.names)
+	return h
+}
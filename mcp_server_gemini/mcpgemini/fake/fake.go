@@ -0,0 +1,236 @@
+// Package fake is a scripted mcpgemini.Provider. It replays a fixed
+// sequence of model turns and records every message it receives, so the
+// agent loop can run end to end against a real MCP server without a
+// network connection or an API key.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/generative-ai-go/genai"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"gopkg.in/yaml.v3"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+// ErrScriptExhausted is returned when the agent sends more messages than
+// the script has turns.
+var ErrScriptExhausted = errors.New("fake: script has no more turns")
+
+// Script is the sequence of turns the fake model plays.
+type Script struct {
+	Turns []Turn `json:"turns" yaml:"turns"`
+}
+
+// Turn is a single scripted model reply, optionally checking the message
+// it answers.
+type Turn struct {
+	Expect *Expect `json:"expect,omitempty" yaml:"expect,omitempty"`
+	Text   string  `json:"text,omitempty" yaml:"text,omitempty"`
+	Calls  []Call  `json:"calls,omitempty" yaml:"calls,omitempty"`
+}
+
+// Call is a scripted tool call.
+type Call struct {
+	Name string         `json:"name" yaml:"name"`
+	Args map[string]any `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+// Expect describes the message a turn expects to receive. Empty fields
+// are not checked.
+type Expect struct {
+	// Text must be contained in the user prompt.
+	Text string `json:"text,omitempty" yaml:"text,omitempty"`
+	// Results lists the names of the tools whose results must be sent,
+	// in order.
+	Results []string `json:"results,omitempty" yaml:"results,omitempty"`
+	// Contains must appear in the JSON encoding of the tool results.
+	Contains string `json:"contains,omitempty" yaml:"contains,omitempty"`
+}
+
+// Load reads a script from a YAML or JSON file.
+func Load(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading script: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a YAML or JSON script. Call arguments are normalised to
+// the types encoding/json produces, as a real model's would be.
+func Parse(data []byte) (*Script, error) {
+	var script Script
+	if err := yaml.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("error parsing script: %w", err)
+	}
+	for i, turn := range script.Turns {
+		for j, call := range turn.Calls {
+			if call.Name == "" {
+				return nil, fmt.Errorf("turn %d: call %d has no name", i+1, j+1)
+			}
+			args, err := normalize(call.Args)
+			if err != nil {
+				return nil, fmt.Errorf("turn %d: call %s: %w", i+1, call.Name, err)
+			}
+			script.Turns[i].Calls[j].Args = args
+		}
+	}
+	return &script, nil
+}
+
+func normalize(args map[string]any) (map[string]any, error) {
+	if args == nil {
+		return map[string]any{}, nil
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding args: %w", err)
+	}
+	out := map[string]any{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("error decoding args: %w", err)
+	}
+	return out, nil
+}
+
+// Provider plays a Script. It is safe for concurrent use.
+type Provider struct {
+	Script *Script
+
+	mu           sync.Mutex
+	next         int
+	received     []mcpgemini.Message
+	declarations []*genai.FunctionDeclaration
+}
+
+// New returns a Provider playing script from its first turn.
+func New(script *Script) *Provider {
+	return &Provider{Script: script}
+}
+
+// SetTools converts the tools into Gemini declarations, exactly as the
+// Gemini provider does, and fails if any of them cannot be converted.
+func (p *Provider) SetTools(tools []mcp_golang.ToolRetType) error {
+	var decls []*genai.FunctionDeclaration
+	for _, tool := range tools {
+		decl, err := mcpgemini.Declaration(tool)
+		if err != nil {
+			return fmt.Errorf("tool %s: %w", tool.Name, err)
+		}
+		decls = append(decls, decl)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.declarations = decls
+	return nil
+}
+
+// Send records msg, checks it against the expectations of the next turn
+// and returns that turn.
+func (p *Provider) Send(ctx context.Context, msg mcpgemini.Message) (*mcpgemini.Reply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.received = append(p.received, msg)
+	if p.next >= len(p.Script.Turns) {
+		return nil, ErrScriptExhausted
+	}
+	turn := p.Script.Turns[p.next]
+	p.next++
+
+	if err := turn.Expect.check(msg); err != nil {
+		return nil, fmt.Errorf("fake: turn %d: %w", p.next, err)
+	}
+
+	reply := &mcpgemini.Reply{Text: turn.Text}
+	for i, call := range turn.Calls {
+		if !p.declared(call.Name) {
+			return nil, fmt.Errorf("fake: turn %d: tool %s was not declared", p.next, call.Name)
+		}
+		reply.Calls = append(reply.Calls, mcpgemini.Call{
+			ID:   fmt.Sprintf("call_%d_%d", p.next, i+1),
+			Name: call.Name,
+			Args: call.Args,
+		})
+	}
+	return reply, nil
+}
+
+func (p *Provider) declared(name string) bool {
+	for _, decl := range p.declarations {
+		if decl.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset rewinds the script and forgets the received messages.
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next = 0
+	p.received = nil
+}
+
+// Received returns the messages sent to the provider so far.
+func (p *Provider) Received() []mcpgemini.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]mcpgemini.Message(nil), p.received...)
+}
+
+// Declarations returns the function declarations built by SetTools.
+func (p *Provider) Declarations() []*genai.FunctionDeclaration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*genai.FunctionDeclaration(nil), p.declarations...)
+}
+
+// Remaining returns the number of turns not played yet.
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.Script.Turns) - p.next
+}
+
+func (e *Expect) check(msg mcpgemini.Message) error {
+	if e == nil {
+		return nil
+	}
+	if e.Text != "" && !strings.Contains(msg.Text, e.Text) {
+		return fmt.Errorf("expected prompt containing %q, got %q", e.Text, msg.Text)
+	}
+	if len(e.Results) > 0 {
+		var names []string
+		for _, res := range msg.Results {
+			names = append(names, res.Call.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(e.Results, ",") {
+			return fmt.Errorf("expected results of %v, got %v", e.Results, names)
+		}
+	}
+	if e.Contains != "" {
+		data, err := json.Marshal(msg.Results)
+		if err != nil {
+			return fmt.Errorf("error encoding results: %w", err)
+		}
+		if !strings.Contains(string(data), e.Contains) {
+			return fmt.Errorf("expected results containing %q, got %s", e.Contains, data)
+		}
+	}
+	return nil
+}
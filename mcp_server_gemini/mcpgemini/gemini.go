@@ -0,0 +1,193 @@
+package mcpgemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"google.golang.org/api/iterator"
+)
+
+// GeminiProvider is a Provider backed by a Gemini chat session.
+type GeminiProvider struct {
+	Model   *genai.GenerativeModel
+	Session *genai.ChatSession
+	// History, if set, keeps the session history within its token budget
+	// before every new prompt.
+	History *HistoryManager
+}
+
+// NewGeminiProvider starts a chat session on model.
+func NewGeminiProvider(model *genai.GenerativeModel) *GeminiProvider {
+	return &GeminiProvider{
+		Model:   model,
+		Session: model.StartChat(),
+	}
+}
+
+func (p *GeminiProvider) SetTools(tools []mcp_golang.ToolRetType) error {
+	p.Model.Tools = GeminiTools(tools)
+	return nil
+}
+
+func (p *GeminiProvider) Send(ctx context.Context, msg Message) (*Reply, error) {
+	if err := p.fitHistory(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Session.SendMessage(ctx, p.insertPrompt(msg)...)
+	if err != nil {
+		return nil, fmt.Errorf("session.SendMessage: %w", err)
+	}
+
+	text, calls, err := splitResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	reply := &Reply{Text: text}
+	for _, call := range calls {
+		reply.Calls = append(reply.Calls, Call{Name: call.Name, Args: call.Args})
+	}
+	return reply, nil
+}
+
+func (p *GeminiProvider) SendStream(ctx context.Context, msg Message, onText func(string), onCall func(Call)) (*Reply, error) {
+	if err := p.fitHistory(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	reply := &Reply{}
+	iter := p.Session.SendMessageStream(ctx, p.insertPrompt(msg)...)
+	for {
+		resp, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return reply, nil
+		}
+		if err != nil {
+			return reply, fmt.Errorf("session.SendMessageStream: %w", err)
+		}
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+			continue
+		}
+
+		for _, part := range resp.Candidates[0].Content.Parts {
+			switch v := part.(type) {
+			case genai.Text:
+				reply.Text += string(v)
+				if onText != nil {
+					onText(string(v))
+				}
+			case genai.FunctionCall:
+				call := Call{Name: v.Name, Args: v.Args}
+				reply.Calls = append(reply.Calls, call)
+				if onCall != nil {
+					onCall(call)
+				}
+			}
+		}
+	}
+}
+
+func (p *GeminiProvider) Reset() {
+	p.Session.History = nil
+}
+
+func (p *GeminiProvider) Checkpoint() func() {
+	history := slices.Clone(p.Session.History)
+	return func() {
+		p.Session.History = history
+	}
+}
+
+// fitHistory trims the session history before a new prompt is sent.
+func (p *GeminiProvider) fitHistory(ctx context.Context, msg Message) error {
+	if p.History == nil || msg.Text == "" {
+		return nil
+	}
+	trim, err := p.History.Fit(ctx, p.Session)
+	if err != nil {
+		return fmt.Errorf("error trimming history: %w", err)
+	}
+	if trim.Dropped > 0 {
+		log.Printf("history: dropped %d exchanges (summarized: %t), %d -> %d tokens",
+			trim.Dropped, trim.Summarized, trim.TokensBefore, trim.TokensAfter)
+	}
+	return nil
+}
+
+// insertPrompt appends the prompt messages of msg to the session history
+// and returns the parts to send. Gemini expects the roles to alternate, so
+// consecutive messages of one role share a turn, and a trailing user
+// message is sent along with msg.
+func (p *GeminiProvider) insertPrompt(msg Message) []genai.Part {
+	var contents []*genai.Content
+	for _, m := range msg.Prompt {
+		role := "user"
+		if m.Role == "assistant" {
+			role = "model"
+		}
+		part := genai.Part(genai.Text(m.ContextText()))
+		if m.Resource != nil && m.Resource.Blob != nil {
+			part = genai.Blob{MIMEType: m.Resource.MIMEType, Data: m.Resource.Blob}
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, part)
+			continue
+		}
+		contents = append(contents, &genai.Content{Role: role, Parts: []genai.Part{part}})
+	}
+
+	parts := geminiParts(msg)
+	if n := len(contents); n > 0 && contents[n-1].Role == "user" {
+		parts = append(contents[n-1].Parts, parts...)
+		contents = contents[:n-1]
+	}
+	p.Session.History = append(p.Session.History, contents...)
+	return parts
+}
+
+// geminiParts converts msg into the parts of a Gemini user turn.
+// Attached resources come first, binary ones as inline blobs.
+func geminiParts(msg Message) []genai.Part {
+	if len(msg.Results) == 0 {
+		var parts []genai.Part
+		for _, res := range msg.Resources {
+			if res.Blob != nil {
+				parts = append(parts, genai.Blob{MIMEType: res.MIMEType, Data: res.Blob})
+			} else {
+				parts = append(parts, genai.Text(res.ContextText()))
+			}
+		}
+		return append(parts, genai.Text(msg.Text))
+	}
+	parts := make([]genai.Part, len(msg.Results))
+	for i, res := range msg.Results {
+		parts[i] = genai.FunctionResponse{Name: res.Call.Name, Response: res.Response}
+	}
+	return parts
+}
+
+// splitResponse separates the text and function call parts of the first
+// candidate in resp.
+func splitResponse(resp *genai.GenerateContentResponse) (string, []genai.FunctionCall, error) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", nil, errors.New("model returned no content")
+	}
+
+	var texts []string
+	var calls []genai.FunctionCall
+	for _, part := range resp.Candidates[0].Content.Parts {
+		switch p := part.(type) {
+		case genai.Text:
+			texts = append(texts, string(p))
+		case genai.FunctionCall:
+			calls = append(calls, p)
+		}
+	}
+	return strings.Join(texts, ""), calls, nil
+}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// content and part mirror genai.Content with an explicit tag for every part
+// kind, since genai.Part is an interface and cannot be decoded directly.
+type content struct {
+	Role  string `json:"role"`
+	Parts []part `json:"parts"`
+}
+
+type part struct {
+	Text             *string           `json:"text,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+	Blob             *blob             `json:"blob,omitempty"`
+	FileData         *fileData         `json:"fileData,omitempty"`
+}
+
+type functionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type functionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response,omitempty"`
+}
+
+type blob struct {
+	MIMEType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+type fileData struct {
+	MIMEType string `json:"mimeType"`
+	URI      string `json:"uri"`
+}
+
+// Marshal encodes a chat history, including function calls and responses,
+// as JSON.
+func Marshal(history []*genai.Content) ([]byte, error) {
+	out := make([]content, 0, len(history))
+	for _, c := range history {
+		if c == nil {
+			continue
+		}
+		enc := content{Role: c.Role, Parts: make([]part, 0, len(c.Parts))}
+		for _, p := range c.Parts {
+			switch v := p.(type) {
+			case genai.Text:
+				text := string(v)
+				enc.Parts = append(enc.Parts, part{Text: &text})
+			case genai.FunctionCall:
+				enc.Parts = append(enc.Parts, part{FunctionCall: &functionCall{Name: v.Name, Args: v.Args}})
+			case genai.FunctionResponse:
+				enc.Parts = append(enc.Parts, part{FunctionResponse: &functionResponse{Name: v.Name, Response: v.Response}})
+			case genai.Blob:
+				enc.Parts = append(enc.Parts, part{Blob: &blob{MIMEType: v.MIMEType, Data: v.Data}})
+			case genai.FileData:
+				enc.Parts = append(enc.Parts, part{FileData: &fileData{MIMEType: v.MIMEType, URI: v.URI}})
+			default:
+				return nil, fmt.Errorf("unsupported part type %T", p)
+			}
+		}
+		out = append(out, enc)
+	}
+	return json.Marshal(out)
+}
+
+// Unmarshal decodes a chat history written by Marshal.
+func Unmarshal(data []byte) ([]*genai.Content, error) {
+	var in []content
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	history := make([]*genai.Content, 0, len(in))
+	for i, c := range in {
+		dec := &genai.Content{Role: c.Role}
+		for _, p := range c.Parts {
+			switch {
+			case p.Text != nil:
+				dec.Parts = append(dec.Parts, genai.Text(*p.Text))
+			case p.FunctionCall != nil:
+				dec.Parts = append(dec.Parts, genai.FunctionCall{Name: p.FunctionCall.Name, Args: p.FunctionCall.Args})
+			case p.FunctionResponse != nil:
+				dec.Parts = append(dec.Parts, genai.FunctionResponse{Name: p.FunctionResponse.Name, Response: p.FunctionResponse.Response})
+			case p.Blob != nil:
+				dec.Parts = append(dec.Parts, genai.Blob{MIMEType: p.Blob.MIMEType, Data: p.Blob.Data})
+			case p.FileData != nil:
+				dec.Parts = append(dec.Parts, genai.FileData{MIMEType: p.FileData.MIMEType, URI: p.FileData.URI})
+			default:
+				return nil, fmt.Errorf("content %d: empty part", i)
+			}
+		}
+		history = append(history, dec)
+	}
+	return history, nil
+}
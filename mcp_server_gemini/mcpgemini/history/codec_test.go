@@ -0,0 +1,45 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// sampleHistory has one part of every kind the agent produces.
+func sampleHistory() []*genai.Content {
+	return []*genai.Content{
+		{Role: "user", Parts: []genai.Part{genai.Text("What is bitcoin worth?")}},
+		{Role: "model", Parts: []genai.Part{genai.FunctionCall{
+			Name: "price",
+			Args: map[string]any{"coin": "bitcoin", "currency": "usd"},
+		}}},
+		{Role: "user", Parts: []genai.Part{genai.FunctionResponse{
+			Name:     "price",
+			Response: map[string]any{"price": 65000.5, "tags": []any{"spot"}},
+		}}},
+		{Role: "model", Parts: []genai.Part{genai.Text("About 65000 dollars.")}},
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	want := sampleHistory()
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %#v, want %#v", got, want)
+	}
+}
+
+func TestUnmarshalEmptyPart(t *testing.T) {
+	if _, err := Unmarshal([]byte(`[{"role": "user", "parts": [{}]}]`)); err == nil {
+		t.Error("decoded a part without a kind")
+	}
+}
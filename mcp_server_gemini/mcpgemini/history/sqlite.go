@@ -0,0 +1,103 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT PRIMARY KEY,
+	updated_at INTEGER NOT NULL,
+	messages   INTEGER NOT NULL,
+	history    BLOB NOT NULL
+)`
+
+// SQLiteStore keeps sessions in an embedded SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens or creates the database at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening history database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating history table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Save(ctx context.Context, id string, history []*genai.Content) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+	data, err := Marshal(history)
+	if err != nil {
+		return fmt.Errorf("error encoding history: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO sessions (id, updated_at, messages, history) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET updated_at = excluded.updated_at, messages = excluded.messages, history = excluded.history`,
+		id, time.Now().UnixNano(), len(history), data)
+	return err
+}
+
+func (s *SQLiteStore) Load(ctx context.Context, id string) ([]*genai.Content, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT history FROM sessions WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+func (s *SQLiteStore) List(ctx context.Context) ([]Info, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, updated_at, messages FROM sessions ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Info
+	for rows.Next() {
+		var info Info
+		var updated int64
+		if err := rows.Scan(&info.ID, &updated, &info.Messages); err != nil {
+			return nil, err
+		}
+		info.UpdatedAt = time.Unix(0, updated)
+		sessions = append(sessions, info)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,66 @@
+// Package history persists Gemini chat histories so that a conversation
+// can be resumed after the program exits.
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// ErrNotFound is returned when loading or deleting an unknown session.
+var ErrNotFound = errors.New("session not found")
+
+// Info describes a stored session.
+type Info struct {
+	ID        string
+	UpdatedAt time.Time
+	Messages  int
+}
+
+// Store saves and restores chat histories by session ID.
+type Store interface {
+	// Save replaces the stored history of the session.
+	Save(ctx context.Context, id string, history []*genai.Content) error
+	// Load returns the history of the session or ErrNotFound.
+	Load(ctx context.Context, id string) ([]*genai.Content, error)
+	// List returns every stored session, most recently updated first.
+	List(ctx context.Context) ([]Info, error)
+	// Delete removes the session or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validateID rejects IDs that could escape a store directory.
+func validateID(id string) error {
+	if !validID.MatchString(id) || id == "." || id == ".." {
+		return fmt.Errorf("invalid session id %q", id)
+	}
+	return nil
+}
+
+// Open returns the store of the given kind, either "file" or "sqlite",
+// backed by path. An empty path uses .history for files and history.db for
+// SQLite.
+func Open(kind, path string) (Store, error) {
+	switch kind {
+	case "file":
+		if path == "" {
+			path = ".history"
+		}
+		return NewFileStore(path)
+	case "sqlite":
+		if path == "" {
+			path = "history.db"
+		}
+		return NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown history store %q", kind)
+	}
+}
@@ -0,0 +1,102 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"file": func(t *testing.T) Store {
+			s, err := NewFileStore(filepath.Join(t.TempDir(), "sessions"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"sqlite": func(t *testing.T) Store {
+			s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+			ctx := context.Background()
+
+			want := sampleHistory()
+			if err := store.Save(ctx, "prices", want); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			got, err := store.Load(ctx, "prices")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load = %#v, want %#v", got, want)
+			}
+
+			sessions, err := store.List(ctx)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(sessions) != 1 || sessions[0].ID != "prices" || sessions[0].Messages != len(want) {
+				t.Errorf("List = %+v, want one session of %d messages", sessions, len(want))
+			}
+
+			if err := store.Delete(ctx, "prices"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := store.Load(ctx, "prices"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load after Delete = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, "prices"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("second Delete = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	tests := []struct {
+		id string
+		ok bool
+	}{
+		{"prices", true},
+		{"2024-01-01_chat.v2", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../x", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"/etc/passwd", false},
+		{"a b", false},
+	}
+	for _, tt := range tests {
+		if err := validateID(tt.id); (err == nil) != tt.ok {
+			t.Errorf("validateID(%q) = %v, want ok %t", tt.id, err, tt.ok)
+		}
+	}
+}
+
+func TestFileStoreRejectsEscapingID(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(filepath.Join(dir, "sessions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(context.Background(), "../x", sampleHistory()); err == nil {
+		t.Fatal("saved a session outside the store directory")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "x*")); len(matches) != 0 {
+		t.Errorf("wrote %v outside the store directory", matches)
+	}
+}
@@ -0,0 +1,229 @@
+package mcpgemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+
+	"example.com/mcp-server/mcpgemini/streamhttp"
+)
+
+// ToolSeparator joins a server name and a tool name into the name the
+// model sees, for example "crypto__bitcoin_price".
+const ToolSeparator = "__"
+
+var serverName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*(_[A-Za-z0-9-]+)*$`)
+
+// ServerConfig describes how to start a single MCP server, or where to
+// reach it over HTTP when URL is set.
+type ServerConfig struct {
+	URL     string            `json:"url,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// Dir is the working directory of the server, relative to the
+	// current directory. It defaults to the current directory.
+	Dir string `json:"dir,omitempty"`
+}
+
+// HostConfig lists the MCP servers of a Host by name.
+type HostConfig struct {
+	Servers map[string]ServerConfig `json:"servers"`
+}
+
+// LoadHostConfig reads a JSON host configuration file.
+func LoadHostConfig(path string) (*HostConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading host config: %w", err)
+	}
+	cfg := &HostConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing host config %s: %w", path, err)
+	}
+	if len(cfg.Servers) == 0 {
+		return nil, fmt.Errorf("host config %s lists no servers", path)
+	}
+	for name, server := range cfg.Servers {
+		if !serverName.MatchString(name) {
+			return nil, fmt.Errorf("invalid server name %q: start with a letter and use letters, digits, - and single underscores", name)
+		}
+		if (server.Command == "") == (server.URL == "") {
+			return nil, fmt.Errorf("server %s needs either a command or a url", name)
+		}
+	}
+	return cfg, nil
+}
+
+// Cmd returns the command starting the server.
+func (c ServerConfig) Cmd() *exec.Cmd {
+	cmd := exec.Command(c.Command, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range c.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	return cmd
+}
+
+// route is the server and original name of a prefixed tool.
+type route struct {
+	server string
+	tool   string
+}
+
+// hostServer is a server of a Host: a Supervisor for local commands or a
+// remoteServer for URLs.
+type hostServer interface {
+	Toolbox
+	Close() error
+}
+
+// remoteServer is a server reached over HTTP.
+type remoteServer struct {
+	*Bridge
+	transport transport.Transport
+}
+
+func (r *remoteServer) Close() error {
+	return r.transport.Close()
+}
+
+// Host runs several supervised MCP servers, or connects to them over
+// HTTP, and presents their tools as one set. Tool names are prefixed with
+// the server name and ToolSeparator, and calls are routed back to the
+// server owning the tool.
+type Host struct {
+	names   []string
+	servers map[string]hostServer
+
+	mu        sync.RWMutex
+	routes    map[string]route
+	resources map[string]string
+}
+
+// StartHost starts and initialises every server in cfg. If any server
+// fails to start the ones already running are stopped.
+func StartHost(ctx context.Context, cfg *HostConfig) (*Host, error) {
+	h := &Host{
+		servers: map[string]hostServer{},
+		routes:  map[string]route{},
+	}
+	for name := range cfg.Servers {
+		h.names = append(h.names, name)
+	}
+	sort.Strings(h.names)
+
+	for _, name := range h.names {
+		server, err := startServer(ctx, name, cfg.Servers[name])
+		if err != nil {
+			h.Close()
+			return nil, err
+		}
+		h.servers[name] = server
+	}
+	return h, nil
+}
+
+func startServer(ctx context.Context, name string, cfg ServerConfig) (hostServer, error) {
+	if cfg.URL != "" {
+		t := streamhttp.NewClient(cfg.URL)
+		bridge, err := Connect(ctx, t)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", name, err)
+		}
+		return &remoteServer{Bridge: bridge, transport: t}, nil
+	}
+
+	server := NewSupervisor(name, cfg.Cmd)
+	if err := server.Start(ctx); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// Servers returns the server names in sorted order.
+func (h *Host) Servers() []string {
+	return h.names
+}
+
+// Server returns the named server, or nil.
+func (h *Host) Server(name string) Toolbox {
+	return h.servers[name]
+}
+
+// ListTools lists the tools of every server with prefixed names. A
+// server failing to list its tools is logged and skipped.
+func (h *Host) ListTools(ctx context.Context) ([]mcp_golang.ToolRetType, error) {
+	var all []mcp_golang.ToolRetType
+	routes := map[string]route{}
+	for _, name := range h.names {
+		tools, err := h.servers[name].ListTools(ctx)
+		if err != nil {
+			log.Printf("server %s: %v", name, err)
+			continue
+		}
+		for _, tool := range tools {
+			prefixed := name + ToolSeparator + tool.Name
+			routes[prefixed] = route{server: name, tool: tool.Name}
+			tool.Name = prefixed
+			all = append(all, tool)
+		}
+	}
+	if len(routes) == 0 && len(h.names) > 0 {
+		return nil, errors.New("no server listed any tools")
+	}
+
+	h.mu.Lock()
+	h.routes = routes
+	h.mu.Unlock()
+	return all, nil
+}
+
+// CallTool routes a prefixed tool name to its server.
+func (h *Host) CallTool(ctx context.Context, name string, args map[string]any) map[string]any {
+	h.mu.RLock()
+	r, ok := h.routes[name]
+	h.mu.RUnlock()
+	if !ok {
+		// Fall back on the prefix for tools listed since ListTools.
+		server, tool, found := strings.Cut(name, ToolSeparator)
+		if !found || h.servers[server] == nil {
+			return map[string]any{"error": fmt.Sprintf("unknown tool %s", name)}
+		}
+		r = route{server: server, tool: tool}
+	}
+	return h.servers[r.server].CallTool(ctx, r.tool, args)
+}
+
+// Close shuts every server down in parallel.
+func (h *Host) Close() error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(h.names))
+	for i, name := range h.names {
+		server := h.servers[name]
+		if server == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = server.Close()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package mcpgemini_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+type convertArgs struct {
+	Amount float64 `json:"amount" jsonschema:"required,description=The amount of bitcoin"`
+}
+
+// newHost returns a Host over the test server as "crypto" and a second
+// server "fx" that also has a hello tool.
+func newHost(t *testing.T) *mcpgemini.Host {
+	t.Helper()
+	fx := connect(t, func(server *mcp_golang.Server) error {
+		err := server.RegisterTool("hello", "Say hello from fx", func(args helloArgs) (*mcp_golang.ToolResponse, error) {
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Hi %s from fx", args.Name))), nil
+		})
+		if err != nil {
+			return err
+		}
+		return server.RegisterTool("convert", "Convert bitcoin to usd", func(args convertArgs) (*mcp_golang.ToolResponse, error) {
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprint(args.Amount * 65000))), nil
+		})
+	})
+	return mcpgemini.NewTestHost(map[string]*mcpgemini.Bridge{"crypto": startServer(t), "fx": fx})
+}
+
+func TestHostListTools(t *testing.T) {
+	host := newHost(t)
+	tools, err := host.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	slices.Sort(names)
+	want := []string{"crypto__hello", "crypto__price", "fx__convert", "fx__hello"}
+	if !slices.Equal(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+}
+
+func TestHostCallTool(t *testing.T) {
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		// listed calls ListTools first, otherwise the prefix routes the call.
+		listed bool
+		want   string
+	}{
+		{name: "routes to the first server", tool: "crypto__hello", args: map[string]any{"name": "Alice"}, listed: true, want: "Hello Alice!"},
+		{name: "same tool on the second server", tool: "fx__hello", args: map[string]any{"name": "Alice"}, listed: true, want: "Hi Alice from fx"},
+		{name: "tool only on the second server", tool: "fx__convert", args: map[string]any{"amount": 2}, listed: true, want: "130000"},
+		{name: "prefix without ListTools", tool: "fx__hello", args: map[string]any{"name": "Bob"}, want: "Hi Bob from fx"},
+		{name: "unprefixed name", tool: "hello", args: map[string]any{"name": "Bob"}, listed: true, want: "unknown tool hello"},
+		{name: "unknown server", tool: "stocks__hello", args: map[string]any{"name": "Bob"}, want: "unknown tool stocks__hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := newHost(t)
+			ctx := context.Background()
+			if tt.listed {
+				if _, err := host.ListTools(ctx); err != nil {
+					t.Fatalf("ListTools: %v", err)
+				}
+			}
+			got := host.CallTool(ctx, tt.tool, tt.args)
+			text, _ := got["response"].(string)
+			if strings.HasPrefix(tt.want, "unknown tool") {
+				text, _ = got["error"].(string)
+			}
+			if text != tt.want {
+				t.Errorf("CallTool(%s) = %v, want %q", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHostConfigServerNames(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"crypto", true},
+		{"crypto-prices", true},
+		{"crypto_prices_v2", true},
+		{"C3", true},
+		{"3d", false},
+		{"-crypto", false},
+		{"_crypto", false},
+		{"crypto_", false},
+		{"crypto__prices", false},
+		{"crypto prices", false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "host.json")
+		config := fmt.Sprintf(`{"servers": {%q: {"command": "server"}}}`, tt.name)
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := mcpgemini.LoadHostConfig(path); (err == nil) != tt.ok {
+			t.Errorf("server name %q: err = %v, want ok %t", tt.name, err, tt.ok)
+		}
+	}
+}
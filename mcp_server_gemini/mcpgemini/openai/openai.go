@@ -0,0 +1,237 @@
+// Package openai is an mcpgemini.Provider for servers implementing the
+// OpenAI chat completions API, such as locally hosted models.
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+// Message is a chat completions message.
+type Message struct {
+	Role       string     `json:"role"`
+	Content    *string    `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a tool call requested by the model.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the name and JSON encoded arguments of a tool call.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type tool struct {
+	Type     string   `json:"type"`
+	Function function `json:"function"`
+}
+
+type function struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type request struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Tools       []tool    `json:"tools,omitempty"`
+	Temperature *float32  `json:"temperature,omitempty"`
+}
+
+type response struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Provider talks to an OpenAI-compatible chat completions endpoint and
+// keeps the conversation in Messages.
+type Provider struct {
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature *float32
+	HTTPClient  *http.Client
+	Messages    []Message
+
+	tools []tool
+}
+
+// New returns a Provider for model served at baseURL, for example
+// http://localhost:8000/v1. apiKey may be empty for local servers.
+func New(baseURL, apiKey, model string) *Provider {
+	return &Provider{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIKey:     apiKey,
+		Model:      model,
+		HTTPClient: &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// SetTools declares the MCP tools as chat completions functions. The JSON
+// schemas are normalised the same way as for Gemini so that $refs and
+// nullable unions are understood by simpler servers.
+func (p *Provider) SetTools(tools []mcp_golang.ToolRetType) error {
+	p.tools = nil
+	for _, t := range tools {
+		params, err := parameters(t.InputSchema)
+		if err != nil {
+			log.Printf("skipping tool %s: %s", t.Name, err)
+			continue
+		}
+		desc := ""
+		if t.Description != nil {
+			desc = *t.Description
+		}
+		p.tools = append(p.tools, tool{
+			Type:     "function",
+			Function: function{Name: t.Name, Description: desc, Parameters: params},
+		})
+	}
+	return nil
+}
+
+func parameters(inputSchema any) (map[string]any, error) {
+	jsonbody, err := json.Marshal(inputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling input schema: %w", err)
+	}
+	raw := map[string]any{}
+	if err := json.Unmarshal(jsonbody, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing input schema: %w", err)
+	}
+	params, warnings := mcpgemini.Normalize(raw)
+	for _, warning := range warnings {
+		log.Printf("warning: schema %s", warning)
+	}
+	delete(params, "$schema")
+	return params, nil
+}
+
+func (p *Provider) Send(ctx context.Context, msg mcpgemini.Message) (*mcpgemini.Reply, error) {
+	// Extend a copy so that a failed request leaves the conversation as it was
+	messages := slices.Clip(p.Messages)
+	for _, m := range msg.Prompt {
+		text := m.ContextText()
+		messages = append(messages, Message{Role: m.Role, Content: &text})
+	}
+	if len(msg.Results) == 0 {
+		text := msg.Text
+		for i := len(msg.Resources) - 1; i >= 0; i-- {
+			text = msg.Resources[i].ContextText() + "\n\n" + text
+		}
+		messages = append(messages, Message{Role: "user", Content: &text})
+	}
+	for _, res := range msg.Results {
+		content, err := json.Marshal(res.Response)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding result of %s: %w", res.Call.Name, err)
+		}
+		text := string(content)
+		messages = append(messages, Message{Role: "tool", Content: &text, ToolCallID: res.Call.ID})
+	}
+
+	answer, err := p.complete(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &mcpgemini.Reply{}
+	if answer.Content != nil {
+		reply.Text = *answer.Content
+	}
+	for _, call := range answer.ToolCalls {
+		args := map[string]any{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("error parsing arguments of %s: %w", call.Function.Name, err)
+			}
+		}
+		reply.Calls = append(reply.Calls, mcpgemini.Call{ID: call.ID, Name: call.Function.Name, Args: args})
+	}
+	p.Messages = append(messages, answer)
+	return reply, nil
+}
+
+func (p *Provider) Reset() {
+	p.Messages = nil
+}
+
+func (p *Provider) Checkpoint() func() {
+	messages := slices.Clone(p.Messages)
+	return func() {
+		p.Messages = messages
+	}
+}
+
+// complete posts messages and returns the first choice.
+func (p *Provider) complete(ctx context.Context, messages []Message) (Message, error) {
+	body, err := json.Marshal(request{
+		Model:       p.Model,
+		Messages:    messages,
+		Tools:       p.tools,
+		Temperature: p.Temperature,
+	})
+	if err != nil {
+		return Message{}, fmt.Errorf("error encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return Message{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("error making request to chat completions API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var out response
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Message{}, fmt.Errorf("error parsing response (status %d): %w", resp.StatusCode, err)
+	}
+	if out.Error != nil {
+		return Message{}, fmt.Errorf("chat completions API error (status %d): %s", resp.StatusCode, out.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("chat completions API returned status %d", resp.StatusCode)
+	}
+	if len(out.Choices) == 0 {
+		return Message{}, errors.New("model returned no choices")
+	}
+	return out.Choices[0].Message, nil
+}
@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/mcp-server/mcpgemini"
+)
+
+// newTestProvider returns a Provider whose server answers every request
+// with status and body.
+func newTestProvider(t *testing.T, status int, body string) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL, "", "test")
+}
+
+func TestSendFailureKeepsMessages(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "api error", status: http.StatusInternalServerError, body: `{"error": {"message": "overloaded"}}`},
+		{name: "no choices", status: http.StatusOK, body: `{"choices": []}`},
+		{
+			name:   "bad arguments",
+			status: http.StatusOK,
+			body:   `{"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [{"id": "1", "type": "function", "function": {"name": "price", "arguments": "{"}}]}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t, tt.status, tt.body)
+			question := "hello"
+			p.Messages = []Message{{Role: "user", Content: &question}}
+
+			if _, err := p.Send(context.Background(), mcpgemini.Message{Text: "What is bitcoin worth?"}); err == nil {
+				t.Fatal("Send succeeded")
+			}
+			if len(p.Messages) != 1 {
+				t.Errorf("got %d messages after a failed Send, want 1", len(p.Messages))
+			}
+		})
+	}
+}
+
+func TestSendAppendsExchange(t *testing.T) {
+	p := newTestProvider(t, http.StatusOK, `{"choices": [{"message": {"role": "assistant", "content": "About 65000 dollars."}}]}`)
+
+	reply, err := p.Send(context.Background(), mcpgemini.Message{Text: "What is bitcoin worth?"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if reply.Text != "About 65000 dollars." {
+		t.Errorf("reply = %q", reply.Text)
+	}
+	if len(p.Messages) != 2 || p.Messages[0].Role != "user" || p.Messages[1].Role != "assistant" {
+		t.Errorf("messages = %+v, want the question and the answer", p.Messages)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	p := newTestProvider(t, http.StatusOK, `{"choices": [{"message": {"role": "assistant", "content": "Hi."}}]}`)
+	restore := p.Checkpoint()
+	if _, err := p.Send(context.Background(), mcpgemini.Message{Text: "hello"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	restore()
+	if len(p.Messages) != 0 {
+		t.Errorf("got %d messages after restoring, want 0", len(p.Messages))
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package mcpgemini
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess kills p, as there is no portable polite signal.
+func terminateProcess(p *os.Process) error {
+	return p.Kill()
+}
+
+func killProcess(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,180 @@
+package mcpgemini
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// PromptMessage is a message of an MCP prompt, inserted into the
+// conversation with its role. Images and embedded resources are carried
+// as a Resource.
+type PromptMessage struct {
+	// Role is "user" or "assistant".
+	Role     string    `json:"role"`
+	Text     string    `json:"text,omitempty"`
+	Resource *Resource `json:"resource,omitempty"`
+}
+
+// ContextText renders the content of the message as text.
+func (m PromptMessage) ContextText() string {
+	if m.Resource != nil {
+		return m.Resource.ContextText()
+	}
+	return m.Text
+}
+
+// Prompter lists MCP prompts and expands them into messages. It is
+// implemented by Bridge, Supervisor and Host.
+type Prompter interface {
+	ListPrompts(ctx context.Context) ([]*mcp_golang.PromptSchema, error)
+	GetPrompt(ctx context.Context, name string, args map[string]string) ([]PromptMessage, error)
+}
+
+// ListPrompts returns every prompt on the MCP server, following
+// pagination.
+func (b *Bridge) ListPrompts(ctx context.Context) ([]*mcp_golang.PromptSchema, error) {
+	var all []*mcp_golang.PromptSchema
+	var cursor *string
+	for {
+		resp, err := b.client.ListPrompts(ctx, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list prompts: %w", err)
+		}
+		all = append(all, resp.Prompts...)
+
+		if resp.NextCursor == nil {
+			return all, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// GetPrompt expands the prompt name with args.
+//
+// mcp-golang's Client.GetPrompt only decodes text messages, so the
+// request goes over the side channel of the bridge like ReadResource.
+func (b *Bridge) GetPrompt(ctx context.Context, name string, args map[string]string) ([]PromptMessage, error) {
+	if args == nil {
+		args = map[string]string{}
+	}
+	raw, err := b.rpc.request(ctx, "prompts/get", map[string]any{"name": name, "arguments": args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s: %w", name, err)
+	}
+
+	var result struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Type     string `json:"type"`
+				Text     string `json:"text"`
+				Data     string `json:"data"`
+				MIMEType string `json:"mimeType"`
+				Resource *struct {
+					URI      string  `json:"uri"`
+					MIMEType string  `json:"mimeType"`
+					Text     *string `json:"text"`
+					Blob     *string `json:"blob"`
+				} `json:"resource"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode prompt %s: %w", name, err)
+	}
+
+	messages := make([]PromptMessage, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		msg := PromptMessage{Role: m.Role}
+		c := m.Content
+		switch c.Type {
+		case "text":
+			msg.Text = c.Text
+		case "image":
+			data, err := base64.StdEncoding.DecodeString(c.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode image of prompt %s: %w", name, err)
+			}
+			msg.Resource = &Resource{URI: "image:" + name, MIMEType: c.MIMEType, Blob: data}
+		case "resource":
+			if c.Resource == nil {
+				return nil, fmt.Errorf("prompt %s: resource message without a resource", name)
+			}
+			res := &Resource{URI: c.Resource.URI, MIMEType: c.Resource.MIMEType}
+			switch {
+			case c.Resource.Text != nil:
+				res.Text = *c.Resource.Text
+			case c.Resource.Blob != nil:
+				res.Blob, err = base64.StdEncoding.DecodeString(*c.Resource.Blob)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode blob of %s: %w", res.URI, err)
+				}
+			}
+			msg.Resource = res
+		default:
+			return nil, fmt.Errorf("prompt %s: unsupported content type %q", name, c.Type)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// ListPrompts lists the prompts of the server.
+func (s *Supervisor) ListPrompts(ctx context.Context) ([]*mcp_golang.PromptSchema, error) {
+	b, err := s.bridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.ListPrompts(ctx)
+}
+
+// GetPrompt expands a prompt of the server.
+func (s *Supervisor) GetPrompt(ctx context.Context, name string, args map[string]string) ([]PromptMessage, error) {
+	b, err := s.bridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetPrompt(ctx, name, args)
+}
+
+// ListPrompts lists the prompts of every server that has any, prefixed
+// with the server name like tools.
+func (h *Host) ListPrompts(ctx context.Context) ([]*mcp_golang.PromptSchema, error) {
+	var all []*mcp_golang.PromptSchema
+	for _, name := range h.names {
+		prompter, ok := h.servers[name].(Prompter)
+		if !ok {
+			continue
+		}
+		prompts, err := prompter.ListPrompts(ctx)
+		if err != nil {
+			log.Printf("server %s: %v", name, err)
+			continue
+		}
+		for _, p := range prompts {
+			prefixed := *p
+			prefixed.Name = name + ToolSeparator + p.Name
+			all = append(all, &prefixed)
+		}
+	}
+	return all, nil
+}
+
+// GetPrompt expands a prompt listed by ListPrompts on its server.
+func (h *Host) GetPrompt(ctx context.Context, name string, args map[string]string) ([]PromptMessage, error) {
+	server, prompt, found := strings.Cut(name, ToolSeparator)
+	if !found || h.servers[server] == nil {
+		return nil, fmt.Errorf("unknown prompt %s", name)
+	}
+	prompter, ok := h.servers[server].(Prompter)
+	if !ok {
+		return nil, fmt.Errorf("server %s has no prompts", server)
+	}
+	return prompter.GetPrompt(ctx, prompt, args)
+}
@@ -0,0 +1,69 @@
+package mcpgemini
+
+import (
+	"context"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+)
+
+// Call is a tool call requested by the model.
+type Call struct {
+	// ID identifies the call for providers that pair results with calls
+	// by ID. It is empty for Gemini, which pairs them by order.
+	ID   string         `json:"id,omitempty"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// CallResult is the response of the MCP server to a Call.
+type CallResult struct {
+	Call     Call           `json:"call"`
+	Response map[string]any `json:"response"`
+}
+
+// Message is a single user turn: either a text prompt, possibly with
+// resources attached as context and preceded by the messages of MCP
+// prompts, or the results of the tool calls of the previous reply.
+type Message struct {
+	// Prompt is added to the conversation history, with its roles,
+	// before Text is sent.
+	Prompt    []PromptMessage `json:"prompt,omitempty"`
+	Text      string          `json:"text,omitempty"`
+	Resources []Resource      `json:"resources,omitempty"`
+	Results   []CallResult    `json:"results,omitempty"`
+}
+
+// Reply is a single model turn.
+type Reply struct {
+	Text  string `json:"text,omitempty"`
+	Calls []Call `json:"calls,omitempty"`
+}
+
+// Provider is a chat model that can call MCP tools. A Provider holds the
+// conversation history, so every Send continues the same conversation.
+type Provider interface {
+	// SetTools declares the MCP tools to the model, converting them into
+	// the provider's own tool declarations.
+	SetTools(tools []mcp_golang.ToolRetType) error
+	// Send sends msg to the model and returns its reply.
+	Send(ctx context.Context, msg Message) (*Reply, error)
+	// Reset clears the conversation history.
+	Reset()
+}
+
+// StreamProvider is a Provider that can stream its replies.
+type StreamProvider interface {
+	Provider
+	// SendStream is like Send but calls onText with every text chunk and
+	// onCall with every tool call as soon as they arrive.
+	SendStream(ctx context.Context, msg Message, onText func(string), onCall func(Call)) (*Reply, error)
+}
+
+// CheckpointProvider is a Provider whose conversation can be put back to
+// an earlier state, such as before a run that failed halfway.
+type CheckpointProvider interface {
+	Provider
+	// Checkpoint returns a function that restores the conversation to
+	// what it is now.
+	Checkpoint() (restore func())
+}
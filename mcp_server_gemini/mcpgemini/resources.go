@@ -0,0 +1,292 @@
+package mcpgemini
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// Resource is the content of an MCP resource, attached to a prompt as
+// context for the model.
+type Resource struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     []byte `json:"blob,omitempty"`
+}
+
+// ContextText renders a text resource as a passage of the prompt, so the
+// model knows where the content came from.
+func (r Resource) ContextText() string {
+	if r.Blob != nil {
+		return fmt.Sprintf("[binary resource %s (%s), %d bytes]", r.URI, r.MIMEType, len(r.Blob))
+	}
+	return fmt.Sprintf("Contents of resource %s (%s):\n%s", r.URI, r.MIMEType, r.Text)
+}
+
+// ResourceReader lists and reads MCP resources. It is implemented by
+// Bridge, Supervisor and Host.
+type ResourceReader interface {
+	ListResources(ctx context.Context) ([]*mcp_golang.ResourceSchema, error)
+	ReadResource(ctx context.Context, uri string) ([]Resource, error)
+}
+
+// ListResources returns every resource on the MCP server, following
+// pagination.
+func (b *Bridge) ListResources(ctx context.Context) ([]*mcp_golang.ResourceSchema, error) {
+	var all []*mcp_golang.ResourceSchema
+	var cursor *string
+	for {
+		resp, err := b.client.ListResources(ctx, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		all = append(all, resp.Resources...)
+
+		if resp.NextCursor == nil {
+			return all, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// ReadResource reads the contents of the resource at uri.
+//
+// mcp-golang's Client.ReadResource cannot decode the contents a server
+// sends back, so the request goes over the side channel of the bridge.
+func (b *Bridge) ReadResource(ctx context.Context, uri string) ([]Resource, error) {
+	raw, err := b.rpc.request(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+
+	var result struct {
+		Contents []struct {
+			URI      string  `json:"uri"`
+			MIMEType string  `json:"mimeType"`
+			Text     *string `json:"text"`
+			Blob     *string `json:"blob"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode resource %s: %w", uri, err)
+	}
+	if len(result.Contents) == 0 {
+		return nil, fmt.Errorf("resource %s not found or empty", uri)
+	}
+
+	resources := make([]Resource, 0, len(result.Contents))
+	for _, c := range result.Contents {
+		res := Resource{URI: c.URI, MIMEType: c.MIMEType}
+		switch {
+		case c.Text != nil:
+			res.Text = *c.Text
+		case c.Blob != nil:
+			res.Blob, err = base64.StdEncoding.DecodeString(*c.Blob)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode blob of %s: %w", c.URI, err)
+			}
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+// ListResources lists the resources of the server.
+func (s *Supervisor) ListResources(ctx context.Context) ([]*mcp_golang.ResourceSchema, error) {
+	b, err := s.bridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.ListResources(ctx)
+}
+
+// ReadResource reads a resource from the server.
+func (s *Supervisor) ReadResource(ctx context.Context, uri string) ([]Resource, error) {
+	b, err := s.bridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.ReadResource(ctx, uri)
+}
+
+// ListResources lists the resources of every server that has any. URIs
+// are not prefixed, as they already identify the resource.
+func (h *Host) ListResources(ctx context.Context) ([]*mcp_golang.ResourceSchema, error) {
+	var all []*mcp_golang.ResourceSchema
+	owners := map[string]string{}
+	for _, name := range h.names {
+		reader, ok := h.servers[name].(ResourceReader)
+		if !ok {
+			continue
+		}
+		resources, err := reader.ListResources(ctx)
+		if err != nil {
+			log.Printf("server %s: %v", name, err)
+			continue
+		}
+		for _, r := range resources {
+			if _, dup := owners[r.Uri]; !dup {
+				owners[r.Uri] = name
+				all = append(all, r)
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Uri < all[j].Uri })
+
+	h.mu.Lock()
+	h.resources = owners
+	h.mu.Unlock()
+	return all, nil
+}
+
+// ReadResource reads uri from the server that lists it.
+func (h *Host) ReadResource(ctx context.Context, uri string) ([]Resource, error) {
+	h.mu.RLock()
+	owner, ok := h.resources[uri]
+	h.mu.RUnlock()
+	if !ok {
+		if _, err := h.ListResources(ctx); err != nil {
+			return nil, err
+		}
+		h.mu.RLock()
+		owner, ok = h.resources[uri]
+		h.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown resource %s", uri)
+		}
+	}
+	reader, ok := h.servers[owner].(ResourceReader)
+	if !ok {
+		return nil, fmt.Errorf("server %s cannot read resources", owner)
+	}
+	return reader.ReadResource(ctx, uri)
+}
+
+// rpcIDBase starts the request IDs of the side channel far above those of
+// the mcp-golang protocol, which count up from zero.
+const rpcIDBase = 1 << 40
+
+// ErrConnectionClosed is returned by requests to a server whose transport
+// has closed, for example because the server process exited.
+var ErrConnectionClosed = errors.New("connection to the mcp server closed")
+
+// rpcTap wraps a transport to send JSON-RPC requests next to those of the
+// mcp-golang client and hand their raw results back, for methods whose
+// responses the client cannot decode. Once the transport closes, pending
+// and new requests fail with ErrConnectionClosed.
+type rpcTap struct {
+	transport.Transport
+
+	nextID    atomic.Int64
+	mu        sync.Mutex
+	pending   map[transport.RequestId]chan *transport.BaseJsonRpcMessage
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newRPCTap(t transport.Transport) *rpcTap {
+	tap := &rpcTap{
+		Transport: t,
+		pending:   map[transport.RequestId]chan *transport.BaseJsonRpcMessage{},
+		closed:    make(chan struct{}),
+	}
+	tap.nextID.Store(rpcIDBase)
+	return tap
+}
+
+// SetCloseHandler fails the pending requests before calling handler when
+// the transport closes, whoever closes it.
+func (t *rpcTap) SetCloseHandler(handler func()) {
+	t.Transport.SetCloseHandler(func() {
+		t.markClosed()
+		if handler != nil {
+			handler()
+		}
+	})
+}
+
+func (t *rpcTap) Close() error {
+	t.markClosed()
+	return t.Transport.Close()
+}
+
+func (t *rpcTap) markClosed() {
+	t.closeOnce.Do(func() { close(t.closed) })
+}
+
+func (t *rpcTap) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	t.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		var id transport.RequestId
+		switch message.Type {
+		case transport.BaseMessageTypeJSONRPCResponseType:
+			id = message.JsonRpcResponse.Id
+		case transport.BaseMessageTypeJSONRPCErrorType:
+			id = message.JsonRpcError.Id
+		default:
+			handler(ctx, message)
+			return
+		}
+
+		t.mu.Lock()
+		ch, ok := t.pending[id]
+		delete(t.pending, id)
+		t.mu.Unlock()
+		if !ok {
+			handler(ctx, message)
+			return
+		}
+		ch <- message
+	})
+}
+
+// request sends a request and waits for its result.
+func (t *rpcTap) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+
+	select {
+	case <-t.closed:
+		return nil, ErrConnectionClosed
+	default:
+	}
+
+	id := transport.RequestId(t.nextID.Add(1))
+	ch := make(chan *transport.BaseJsonRpcMessage, 1)
+	t.mu.Lock()
+	t.pending[id] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	req := &transport.BaseJSONRPCRequest{Id: id, Jsonrpc: "2.0", Method: method, Params: data}
+	if err := t.Transport.Send(ctx, transport.NewBaseMessageRequest(req)); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.Type == transport.BaseMessageTypeJSONRPCErrorType {
+			return nil, fmt.Errorf("server error %d: %s", reply.JsonRpcError.Error.Code, reply.JsonRpcError.Error.Message)
+		}
+		return reply.JsonRpcResponse.Result, nil
+	case <-t.closed:
+		return nil, ErrConnectionClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
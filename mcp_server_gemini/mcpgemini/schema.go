@@ -0,0 +1,378 @@
+package mcpgemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Property is a single node of a tool's JSON schema. Objects carry their
+// own Properties and Required list, arrays carry the schema of their Items.
+type Property struct {
+	Description string              `json:"description"`
+	Type        string              `json:"type"`
+	Format      string              `json:"format"`
+	Enum        []any               `json:"enum"`
+	Default     any                 `json:"default"`
+	Nullable    bool                `json:"nullable"`
+	Properties  map[string]Property `json:"properties"`
+	Items       *Property           `json:"items"`
+	Required    []string            `json:"required"`
+}
+
+type GSchema struct {
+	Schema string `json:"$schema"`
+	Property
+}
+
+func getType(kind string) (genai.Type, error) {
+	var gType genai.Type
+	switch kind {
+	case "object":
+		gType = genai.TypeObject
+	case "array":
+		gType = genai.TypeArray
+	case "string":
+		gType = genai.TypeString
+	case "number":
+		gType = genai.TypeNumber
+	case "integer":
+		gType = genai.TypeInteger
+	case "boolean":
+		gType = genai.TypeBoolean
+	default:
+		return 0, fmt.Errorf("type not found in gemini Type: %s", kind)
+	}
+
+	return gType, nil
+}
+
+// supportedFormats lists the formats Gemini accepts for each primitive type.
+// Any other format is kept as a hint in the description instead.
+var supportedFormats = map[genai.Type][]string{
+	genai.TypeString:  {"enum", "date-time"},
+	genai.TypeNumber:  {"float", "double"},
+	genai.TypeInteger: {"int32", "int64"},
+}
+
+func (g GSchema) Convert() (*genai.Schema, error) {
+	return g.Property.Convert()
+}
+
+// Convert walks the schema tree and builds the matching genai.Schema,
+// including nested object properties and array items.
+func (p Property) Convert() (*genai.Schema, error) {
+	gType, err := getType(p.Type)
+	if err != nil {
+		return nil, err
+	}
+	res := &genai.Schema{
+		Type:     gType,
+		Nullable: p.Nullable,
+		Required: p.Required,
+	}
+
+	var hints []string
+	if p.Format != "" {
+		if slices.Contains(supportedFormats[gType], p.Format) {
+			res.Format = p.Format
+		} else {
+			hints = append(hints, fmt.Sprintf("format: %s", p.Format))
+		}
+	}
+	if len(p.Enum) > 0 {
+		values := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			values[i] = fmt.Sprint(v)
+		}
+		// Gemini only supports enums on strings
+		if gType == genai.TypeString {
+			res.Enum = values
+			res.Format = "enum"
+		} else {
+			hints = append(hints, fmt.Sprintf("one of: %s", strings.Join(values, ", ")))
+		}
+	}
+	if p.Default != nil {
+		hints = append(hints, fmt.Sprintf("default: %v", p.Default))
+	}
+	res.Description = p.Description
+	if len(hints) > 0 {
+		res.Description = strings.TrimSpace(fmt.Sprintf("%s (%s)", p.Description, strings.Join(hints, "; ")))
+	}
+
+	if p.Items != nil {
+		items, err := p.Items.Convert()
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		res.Items = items
+	}
+
+	if len(p.Properties) > 0 {
+		// Convert properties to map of genai.Schema
+		res.Properties = make(map[string]*genai.Schema, len(p.Properties))
+		for k, v := range p.Properties {
+			prop, err := v.Convert()
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", k, err)
+			}
+			res.Properties[k] = prop
+		}
+	}
+
+	return res, nil
+}
+
+// schemaNormalizer rewrites a raw JSON schema into the subset understood by
+// Property: $refs are inlined, anyOf/oneOf are flattened and anything that
+// cannot be represented is simplified and recorded as a warning.
+type schemaNormalizer struct {
+	defs     map[string]any
+	stack    []string
+	warnings []string
+}
+
+// Normalize returns a copy of schema that Property can convert, together
+// with warnings describing every construct that had to be simplified.
+func Normalize(schema map[string]any) (map[string]any, []string) {
+	n := &schemaNormalizer{defs: map[string]any{}}
+	for _, key := range []string{"definitions", "$defs"} {
+		if defs, ok := schema[key].(map[string]any); ok {
+			for name, def := range defs {
+				n.defs["#/"+key+"/"+name] = def
+			}
+		}
+	}
+	res := n.walk(schema, "")
+	if s, ok := schema["$schema"]; ok {
+		res["$schema"] = s
+	}
+	return res, n.warnings
+}
+
+func (n *schemaNormalizer) warn(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	n.warnings = append(n.warnings, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (n *schemaNormalizer) walk(node map[string]any, path string) map[string]any {
+	if ref, ok := node["$ref"].(string); ok {
+		return n.resolve(ref, node, path)
+	}
+
+	res := map[string]any{}
+	for k, v := range node {
+		switch k {
+		case "$ref", "$schema", "$defs", "definitions", "anyOf", "oneOf", "allOf":
+		default:
+			res[k] = v
+		}
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		alts, ok := node[key].([]any)
+		if !ok {
+			continue
+		}
+		var options []map[string]any
+		for _, alt := range alts {
+			m, ok := alt.(map[string]any)
+			if !ok {
+				continue
+			}
+			if m["type"] == "null" {
+				res["nullable"] = true
+				continue
+			}
+			options = append(options, m)
+		}
+		if len(options) == 0 {
+			continue
+		}
+		if len(options) > 1 {
+			n.warn(path, "%s with %d alternatives is not supported, using the first one", key, len(options))
+		}
+		res = merge(n.walk(options[0], path), res)
+	}
+
+	if alts, ok := node["allOf"].([]any); ok {
+		for _, alt := range alts {
+			if m, ok := alt.(map[string]any); ok {
+				res = merge(res, n.walk(m, path))
+			}
+		}
+	}
+
+	n.normalizeType(res, path)
+
+	if props, ok := res["properties"].(map[string]any); ok {
+		normalized := make(map[string]any, len(props))
+		for name, prop := range props {
+			if m, ok := prop.(map[string]any); ok {
+				normalized[name] = n.walk(m, path+"/"+name)
+			}
+		}
+		res["properties"] = normalized
+	}
+
+	switch items := res["items"].(type) {
+	case map[string]any:
+		res["items"] = n.walk(items, path+"/items")
+	case []any:
+		n.warn(path, "tuple items are not supported, using the first item schema")
+		delete(res, "items")
+		if len(items) > 0 {
+			if first, ok := items[0].(map[string]any); ok {
+				res["items"] = n.walk(first, path+"/items")
+			}
+		}
+	}
+
+	return res
+}
+
+// resolve inlines a local $ref. Sibling keywords such as description take
+// precedence over the referenced definition.
+func (n *schemaNormalizer) resolve(ref string, node map[string]any, path string) map[string]any {
+	siblings := map[string]any{}
+	for k, v := range node {
+		if k != "$ref" {
+			siblings[k] = v
+		}
+	}
+
+	if slices.Contains(n.stack, ref) {
+		n.warn(path, "recursive reference %s is not supported, using a plain object", ref)
+		return merge(map[string]any{"type": "object"}, siblings)
+	}
+	def, ok := n.defs[ref].(map[string]any)
+	if !ok {
+		n.warn(path, "unresolved reference %s, using a string", ref)
+		return merge(map[string]any{"type": "string"}, siblings)
+	}
+
+	n.stack = append(n.stack, ref)
+	defer func() { n.stack = n.stack[:len(n.stack)-1] }()
+	return n.walk(merge(def, siblings), path)
+}
+
+// normalizeType reduces the type keyword to a single Gemini-compatible type.
+func (n *schemaNormalizer) normalizeType(node map[string]any, path string) {
+	if types, ok := node["type"].([]any); ok {
+		var kinds []string
+		for _, t := range types {
+			if t == "null" {
+				node["nullable"] = true
+				continue
+			}
+			kinds = append(kinds, fmt.Sprint(t))
+		}
+		if len(kinds) > 1 {
+			n.warn(path, "multiple types %v are not supported, using %s", kinds, kinds[0])
+		}
+		if len(kinds) > 0 {
+			node["type"] = kinds[0]
+		} else {
+			node["type"] = "null"
+		}
+	}
+
+	kind, _ := node["type"].(string)
+	switch {
+	case kind == "":
+		switch {
+		case node["properties"] != nil:
+			node["type"] = "object"
+		case node["items"] != nil:
+			node["type"] = "array"
+		default:
+			if _, ok := node["enum"]; !ok {
+				n.warn(path, "missing type, using a string")
+			}
+			node["type"] = "string"
+		}
+	case kind == "null":
+		n.warn(path, "null type is not supported, using a nullable string")
+		node["type"] = "string"
+		node["nullable"] = true
+	default:
+		if _, err := getType(kind); err != nil {
+			n.warn(path, "%s, using a string", err)
+			node["type"] = "string"
+		}
+	}
+}
+
+// merge returns a copy of base overlaid with the keys of over. Properties
+// and required lists are combined rather than replaced.
+func merge(base, over map[string]any) map[string]any {
+	res := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range over {
+		switch k {
+		case "properties":
+			props := map[string]any{}
+			if p, ok := res[k].(map[string]any); ok {
+				for name, prop := range p {
+					props[name] = prop
+				}
+			}
+			if p, ok := v.(map[string]any); ok {
+				for name, prop := range p {
+					props[name] = prop
+				}
+			}
+			res[k] = props
+		case "required":
+			var required []any
+			if r, ok := res[k].([]any); ok {
+				required = append(required, r...)
+			}
+			if r, ok := v.([]any); ok {
+				for _, name := range r {
+					if !slices.Contains(required, name) {
+						required = append(required, name)
+					}
+				}
+			}
+			res[k] = required
+		default:
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// ConvertInputSchema converts an MCP tool input schema into a genai.Schema.
+// The returned warnings list every construct that was simplified on the way.
+func ConvertInputSchema(input any) (*genai.Schema, []string, error) {
+	jsonbody, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling input schema: %w", err)
+	}
+	raw := map[string]any{}
+	if err := json.Unmarshal(jsonbody, &raw); err != nil {
+		return nil, nil, fmt.Errorf("error parsing input schema: %w", err)
+	}
+
+	normalized, warnings := Normalize(raw)
+	jsonbody, err = json.Marshal(normalized)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("error marshalling normalized schema: %w", err)
+	}
+	gschema := GSchema{}
+	if err := json.Unmarshal(jsonbody, &gschema); err != nil {
+		return nil, warnings, fmt.Errorf("error parsing normalized schema: %w", err)
+	}
+
+	res, err := gschema.Convert()
+	return res, warnings, err
+}
@@ -0,0 +1,248 @@
+package mcpgemini
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestConvertInputSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		// check inspects the converted schema.
+		check func(t *testing.T, s *genai.Schema)
+		// warnings are substrings expected in the warnings, in order.
+		warnings []string
+	}{
+		{
+			name: "ref to defs is inlined",
+			schema: `{
+				"type": "object",
+				"properties": {"filter": {"$ref": "#/$defs/Filter", "description": "The filter"}},
+				"$defs": {"Filter": {
+					"type": "object",
+					"description": "A filter",
+					"properties": {"field": {"type": "string"}},
+					"required": ["field"]
+				}}
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				filter := s.Properties["filter"]
+				if filter == nil || filter.Type != genai.TypeObject {
+					t.Fatalf("filter = %+v, want an object", filter)
+				}
+				if filter.Description != "The filter" {
+					t.Errorf("description = %q, want the one next to $ref", filter.Description)
+				}
+				if field := filter.Properties["field"]; field == nil || field.Type != genai.TypeString {
+					t.Errorf("field = %+v, want a string", field)
+				}
+				if len(filter.Required) != 1 || filter.Required[0] != "field" {
+					t.Errorf("required = %v, want [field]", filter.Required)
+				}
+			},
+		},
+		{
+			name: "ref to definitions is inlined",
+			schema: `{
+				"type": "object",
+				"properties": {"coin": {"$ref": "#/definitions/Coin"}},
+				"definitions": {"Coin": {"type": "string", "enum": ["bitcoin", "ethereum"]}}
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				coin := s.Properties["coin"]
+				if coin == nil || coin.Type != genai.TypeString || len(coin.Enum) != 2 {
+					t.Fatalf("coin = %+v, want a string enum", coin)
+				}
+			},
+		},
+		{
+			name: "recursive reference becomes a plain object",
+			schema: `{
+				"type": "object",
+				"properties": {"node": {"$ref": "#/$defs/Node"}},
+				"$defs": {"Node": {
+					"type": "object",
+					"properties": {"next": {"$ref": "#/$defs/Node"}, "value": {"type": "integer"}}
+				}}
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				node := s.Properties["node"]
+				if node == nil || node.Properties["value"] == nil {
+					t.Fatalf("node = %+v, want the inlined definition", node)
+				}
+				next := node.Properties["next"]
+				if next == nil || next.Type != genai.TypeObject || len(next.Properties) != 0 {
+					t.Errorf("next = %+v, want a plain object", next)
+				}
+			},
+			warnings: []string{"/node/next: recursive reference #/$defs/Node"},
+		},
+		{
+			name:   "unresolved reference becomes a string",
+			schema: `{"type": "object", "properties": {"x": {"$ref": "#/$defs/Missing"}}}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				if x := s.Properties["x"]; x == nil || x.Type != genai.TypeString {
+					t.Errorf("x = %+v, want a string", x)
+				}
+			},
+			warnings: []string{"/x: unresolved reference #/$defs/Missing"},
+		},
+		{
+			name: "anyOf with null becomes nullable",
+			schema: `{
+				"type": "object",
+				"properties": {"note": {"anyOf": [{"type": "string"}, {"type": "null"}], "description": "A note"}},
+				"required": ["note"]
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				note := s.Properties["note"]
+				if note == nil || note.Type != genai.TypeString || !note.Nullable {
+					t.Fatalf("note = %+v, want a nullable string", note)
+				}
+				if note.Description != "A note" {
+					t.Errorf("description = %q, want A note", note.Description)
+				}
+			},
+		},
+		{
+			name: "oneOf with several alternatives keeps the first",
+			schema: `{
+				"type": "object",
+				"properties": {"amount": {"oneOf": [{"type": "number"}, {"type": "string"}, {"type": "null"}]}},
+				"required": ["amount"]
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				amount := s.Properties["amount"]
+				if amount == nil || amount.Type != genai.TypeNumber || !amount.Nullable {
+					t.Fatalf("amount = %+v, want a nullable number", amount)
+				}
+			},
+			warnings: []string{"/amount: oneOf with 2 alternatives is not supported"},
+		},
+		{
+			name: "multi-type array keeps the first type",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"count": {"type": ["integer", "null"]},
+					"value": {"type": ["string", "number"]}
+				},
+				"required": ["count", "value"]
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				if count := s.Properties["count"]; count == nil || count.Type != genai.TypeInteger || !count.Nullable {
+					t.Errorf("count = %+v, want a nullable integer", count)
+				}
+				if value := s.Properties["value"]; value == nil || value.Type != genai.TypeString || value.Nullable {
+					t.Errorf("value = %+v, want a string", value)
+				}
+			},
+			warnings: []string{"/value: multiple types [string number] are not supported, using string"},
+		},
+		{
+			name: "tuple items keep the first item schema",
+			schema: `{
+				"type": "object",
+				"properties": {"point": {"type": "array", "items": [{"type": "number"}, {"type": "string"}]}}
+			}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				point := s.Properties["point"]
+				if point == nil || point.Type != genai.TypeArray || point.Items == nil || point.Items.Type != genai.TypeNumber {
+					t.Fatalf("point = %+v, want an array of numbers", point)
+				}
+			},
+			warnings: []string{"/point: tuple items are not supported"},
+		},
+		{
+			name:   "unknown type becomes a string",
+			schema: `{"type": "object", "properties": {"when": {"type": "timestamp"}}}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				if when := s.Properties["when"]; when == nil || when.Type != genai.TypeString {
+					t.Errorf("when = %+v, want a string", when)
+				}
+			},
+			warnings: []string{"/when: type not found in gemini Type: timestamp, using a string"},
+		},
+		{
+			name:   "missing type becomes a string",
+			schema: `{"type": "object", "properties": {"anything": {"description": "Anything"}}}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				if anything := s.Properties["anything"]; anything == nil || anything.Type != genai.TypeString {
+					t.Errorf("anything = %+v, want a string", anything)
+				}
+			},
+			warnings: []string{"/anything: missing type, using a string"},
+		},
+		{
+			name:   "optional properties are not nullable",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}, "required": ["a"]}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				if s.Properties["a"].Nullable || s.Properties["b"].Nullable {
+					t.Errorf("a nullable %t, b nullable %t, want neither", s.Properties["a"].Nullable, s.Properties["b"].Nullable)
+				}
+			},
+		},
+		{
+			name:   "explicit nullable is kept",
+			schema: `{"type": "object", "properties": {"a": {"type": "string", "nullable": true}}}`,
+			check: func(t *testing.T, s *genai.Schema) {
+				if !s.Properties["a"].Nullable {
+					t.Error("a is not nullable")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input map[string]any
+			if err := json.Unmarshal([]byte(tt.schema), &input); err != nil {
+				t.Fatalf("bad test schema: %v", err)
+			}
+			s, warnings, err := ConvertInputSchema(input)
+			if err != nil {
+				t.Fatalf("ConvertInputSchema: %v", err)
+			}
+			tt.check(t, s)
+
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("warnings = %q, want %d matching %q", warnings, len(tt.warnings), tt.warnings)
+			}
+			for i, want := range tt.warnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("warning %d = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeMutualRecursion(t *testing.T) {
+	input := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"a": map[string]any{"$ref": "#/$defs/A"},
+		},
+		"$defs": map[string]any{
+			"A": map[string]any{"type": "object", "properties": map[string]any{"b": map[string]any{"$ref": "#/$defs/B"}}},
+			"B": map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"$ref": "#/$defs/A"}}},
+		},
+	}
+
+	res, warnings := Normalize(input)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "/a/b/a: recursive reference #/$defs/A") {
+		t.Fatalf("warnings = %q, want one recursive reference at /a/b/a", warnings)
+	}
+	if _, ok := res["$defs"]; ok {
+		t.Error("normalized schema still has $defs")
+	}
+	b := res["properties"].(map[string]any)["a"].(map[string]any)["properties"].(map[string]any)["b"].(map[string]any)
+	inner := b["properties"].(map[string]any)["a"].(map[string]any)
+	if inner["type"] != "object" || inner["properties"] != nil {
+		t.Errorf("innermost a = %v, want a plain object", inner)
+	}
+}
@@ -0,0 +1,72 @@
+package mcpgemini
+
+import (
+	"context"
+	"errors"
+)
+
+// RunStream is like Run but streams the model output. onText is called
+// with every text chunk as soon as it arrives. Tool calls are started as
+// soon as they appear in the stream, and once the model's turn is over
+// their results are sent back and streaming resumes. Providers that cannot
+// stream are sent whole messages and onText receives each reply at once.
+// Calls made past MaxSteps are not started but declined as in Run.
+func (a *Agent) RunStream(ctx context.Context, prompt string, onText func(string)) (*Result, error) {
+	maxSteps := a.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	res := &Result{}
+	msg := a.prompt(prompt)
+	for {
+		d := a.newDispatcher(ctx)
+		overBudget := len(res.Steps) >= maxSteps
+		onCall := func(call Call) {
+			if !overBudget {
+				d.dispatch(call)
+			}
+		}
+
+		reply, err := a.send(ctx, msg, onText, onCall)
+		if err != nil {
+			d.wait()
+			return res, err
+		}
+		if len(reply.Calls) == 0 {
+			res.Text = reply.Text
+			return res, nil
+		}
+		if overBudget {
+			text, err := a.decline(reply.Calls, func(msg Message) (*Reply, error) {
+				return a.send(ctx, msg, onText, func(Call) {})
+			})
+			res.Text = text
+			return res, errors.Join(ErrMaxSteps, err)
+		}
+
+		step := Step{Text: reply.Text, Calls: d.wait()}
+		res.Steps = append(res.Steps, step)
+		msg = resultsMessage(step)
+	}
+}
+
+// send streams msg when the provider supports it and emulates streaming
+// otherwise.
+func (a *Agent) send(ctx context.Context, msg Message, onText func(string), onCall func(Call)) (*Reply, error) {
+	if sp, ok := a.Provider.(StreamProvider); ok {
+		return sp.SendStream(ctx, msg, onText, onCall)
+	}
+
+	reply, err := a.Provider.Send(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if onText != nil && reply.Text != "" {
+		onText(reply.Text)
+	}
+	for _, call := range reply.Calls {
+		onCall(call)
+	}
+	return reply, nil
+}
@@ -0,0 +1,115 @@
+package streamhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// Client is an MCP client transport POSTing every message to a server
+// URL. Unlike the stateless transport of mcp-golang, it honours the
+// context of each request, so timeouts and cancellation reach the HTTP
+// call.
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+	// Header is added to every request, for example for authorisation.
+	Header http.Header
+
+	mu      sync.Mutex
+	handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	onError func(error)
+	onClose func()
+}
+
+// NewClient returns a transport for the server endpoint at url, for
+// example http://localhost:8080/mcp.
+func NewClient(url string) *Client {
+	return &Client{URL: url, HTTPClient: http.DefaultClient, Header: http.Header{}}
+}
+
+func (c *Client) Start(ctx context.Context) error {
+	return nil
+}
+
+// Send posts message and hands the response in the body, if any, to the
+// message handler.
+func (c *Client) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	reply, err := decode(body)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	handler := c.handler
+	c.mu.Unlock()
+	if handler != nil {
+		handler(ctx, reply)
+	}
+	return nil
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	onClose := c.onClose
+	c.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+	return nil
+}
+
+func (c *Client) SetCloseHandler(handler func()) {
+	c.mu.Lock()
+	c.onClose = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) SetErrorHandler(handler func(error)) {
+	c.mu.Lock()
+	c.onError = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	c.mu.Lock()
+	c.handler = handler
+	c.mu.Unlock()
+}
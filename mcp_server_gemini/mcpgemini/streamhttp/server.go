@@ -0,0 +1,259 @@
+// Package streamhttp carries MCP over HTTP in the request/response style
+// of the streamable HTTP transport: every JSON-RPC message is POSTed to a
+// single endpoint, and the response to a request comes back as the JSON
+// body of the same HTTP response. Server-initiated streams are not
+// supported, so one long-lived server can answer many stateless clients.
+package streamhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// DefaultEndpoint is the path the server answers on.
+const DefaultEndpoint = "/mcp"
+
+// maxBodySize bounds the size of a single JSON-RPC message.
+const maxBodySize = 4 << 20
+
+// Server is an MCP server transport listening for HTTP requests. Request
+// IDs are rewritten internally so that concurrent clients using the same
+// IDs do not collide.
+type Server struct {
+	Addr     string
+	Endpoint string
+
+	server  *http.Server
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[transport.RequestId]chan *transport.BaseJsonRpcMessage
+	handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	onError func(error)
+	onClose func()
+}
+
+// NewServer returns a transport that will listen on addr, for example
+// ":8080", and answer on DefaultEndpoint.
+func NewServer(addr string) *Server {
+	return &Server{
+		Addr:     addr,
+		Endpoint: DefaultEndpoint,
+		pending:  map[transport.RequestId]chan *transport.BaseJsonRpcMessage{},
+	}
+}
+
+// Start listens on Addr and serves in the background. It returns once
+// the listener is ready, so that address errors are reported.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(s.Endpoint, s)
+	s.mu.Lock()
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	s.mu.Unlock()
+
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.handleError(fmt.Errorf("http server: %w", err))
+		}
+	}()
+	return nil
+}
+
+// ServeHTTP handles a single JSON-RPC message.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	message, err := decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+	if handler == nil {
+		http.Error(w, "server is not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	if message.Type != transport.BaseMessageTypeJSONRPCRequestType {
+		handler(r.Context(), message)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Swap in an ID unique to this server and wait for its response.
+	req := message.JsonRpcRequest
+	clientID := req.Id
+	id := transport.RequestId(s.nextID.Add(1))
+	req.Id = id
+	ch := make(chan *transport.BaseJsonRpcMessage, 1)
+	s.mu.Lock()
+	s.pending[id] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	handler(r.Context(), message)
+
+	var reply *transport.BaseJsonRpcMessage
+	select {
+	case reply = <-ch:
+	case <-r.Context().Done():
+		return
+	}
+	switch reply.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		reply.JsonRpcResponse.Id = clientID
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		reply.JsonRpcError.Id = clientID
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// Send delivers a response to the HTTP request waiting for it. Messages
+// that answer no pending request, such as notifications, are dropped as
+// there is no stream to send them on.
+func (s *Server) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	var id transport.RequestId
+	switch message.Type {
+	case transport.BaseMessageTypeJSONRPCResponseType:
+		id = message.JsonRpcResponse.Id
+	case transport.BaseMessageTypeJSONRPCErrorType:
+		id = message.JsonRpcError.Id
+	default:
+		return nil
+	}
+
+	s.mu.Lock()
+	ch := s.pending[id]
+	s.mu.Unlock()
+	if ch == nil {
+		return fmt.Errorf("no pending request with id %d", id)
+	}
+	select {
+	case ch <- message:
+	default:
+	}
+	return nil
+}
+
+// Close stops the HTTP server.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	server := s.server
+	onClose := s.onClose
+	s.mu.Unlock()
+
+	var err error
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = server.Shutdown(ctx)
+	}
+	if onClose != nil {
+		onClose()
+	}
+	return err
+}
+
+func (s *Server) SetCloseHandler(handler func()) {
+	s.mu.Lock()
+	s.onClose = handler
+	s.mu.Unlock()
+}
+
+func (s *Server) SetErrorHandler(handler func(error)) {
+	s.mu.Lock()
+	s.onError = handler
+	s.mu.Unlock()
+}
+
+func (s *Server) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	s.mu.Lock()
+	s.handler = handler
+	s.mu.Unlock()
+}
+
+func (s *Server) handleError(err error) {
+	s.mu.Lock()
+	onError := s.onError
+	s.mu.Unlock()
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// decode parses a single JSON-RPC message of any kind.
+func decode(body []byte) (*transport.BaseJsonRpcMessage, error) {
+	var probe struct {
+		ID     *json.RawMessage `json:"id"`
+		Method *string          `json:"method"`
+		Error  *json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return nil, fmt.Errorf("invalid JSON-RPC message: %w", err)
+	}
+
+	switch {
+	case probe.Method != nil && probe.ID != nil:
+		var req transport.BaseJSONRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("invalid request: %w", err)
+		}
+		return transport.NewBaseMessageRequest(&req), nil
+	case probe.Method != nil:
+		var n transport.BaseJSONRPCNotification
+		if err := json.Unmarshal(body, &n); err != nil {
+			return nil, fmt.Errorf("invalid notification: %w", err)
+		}
+		return transport.NewBaseMessageNotification(&n), nil
+	case probe.Error != nil:
+		var e transport.BaseJSONRPCError
+		if err := json.Unmarshal(body, &e); err != nil {
+			return nil, fmt.Errorf("invalid error response: %w", err)
+		}
+		return transport.NewBaseMessageError(&e), nil
+	default:
+		var resp transport.BaseJSONRPCResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+		return transport.NewBaseMessageResponse(&resp), nil
+	}
+}
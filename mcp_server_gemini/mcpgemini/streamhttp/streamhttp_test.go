@@ -0,0 +1,138 @@
+package streamhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// newTestServer serves s on DefaultEndpoint and returns its URL.
+func newTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(DefaultEndpoint, s)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts.URL + DefaultEndpoint
+}
+
+func TestConcurrentClientsWithSameID(t *testing.T) {
+	s := NewServer("")
+	// Hold every request until both have arrived, then echo its params.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	var mu sync.Mutex
+	seen := map[transport.RequestId]bool{}
+	s.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		req := message.JsonRpcRequest
+		mu.Lock()
+		seen[req.Id] = true
+		mu.Unlock()
+		arrived.Done()
+		arrived.Wait()
+		s.Send(ctx, transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{
+			Id:      req.Id,
+			Jsonrpc: "2.0",
+			Result:  req.Params,
+		}))
+	})
+	url := newTestServer(t, s)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var reply *transport.BaseJsonRpcMessage
+			c := NewClient(url)
+			c.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+				reply = message
+			})
+
+			params, _ := json.Marshal(map[string]string{"name": name})
+			err := c.Send(context.Background(), transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+				Id:      1,
+				Jsonrpc: "2.0",
+				Method:  "echo",
+				Params:  params,
+			}))
+			if err != nil {
+				t.Errorf("%s: Send: %v", name, err)
+				return
+			}
+			if reply == nil || reply.Type != transport.BaseMessageTypeJSONRPCResponseType {
+				t.Errorf("%s: reply = %+v, want a response", name, reply)
+				return
+			}
+			if reply.JsonRpcResponse.Id != 1 {
+				t.Errorf("%s: reply id = %d, want the client's id 1", name, reply.JsonRpcResponse.Id)
+			}
+			var got map[string]string
+			if err := json.Unmarshal(reply.JsonRpcResponse.Result, &got); err != nil || got["name"] != name {
+				t.Errorf("%s: got the reply %s", name, reply.JsonRpcResponse.Result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 2 {
+		t.Errorf("server saw ids %v, want two distinct ids", seen)
+	}
+}
+
+func TestServerRejectsNonPost(t *testing.T) {
+	s := NewServer("")
+	s.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		t.Errorf("handled %+v", message)
+	})
+	url := newTestServer(t, s)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("GET = %d with Allow %q, want 405 allowing POST", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
+
+func TestServerAcceptsNotification(t *testing.T) {
+	s := NewServer("")
+	got := make(chan string, 1)
+	s.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		got <- message.JsonRpcNotification.Method
+	})
+	url := newTestServer(t, s)
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(`{"jsonrpc": "2.0", "method": "notifications/initialized"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", resp.StatusCode)
+	}
+	if method := <-got; method != "notifications/initialized" {
+		t.Errorf("handled %s", method)
+	}
+}
+
+func TestClientReportsErrorStatus(t *testing.T) {
+	url := newTestServer(t, NewServer(""))
+
+	err := NewClient(url).Send(context.Background(), transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+		Id:      1,
+		Jsonrpc: "2.0",
+		Method:  "ping",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "status 503: server is not ready") {
+		t.Fatalf("err = %v, want the status of a server without a handler", err)
+	}
+}
@@ -0,0 +1,430 @@
+package mcpgemini
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
+	"github.com/metoro-io/mcp-golang/transport/stdio"
+)
+
+// Defaults for the Supervisor fields left unset.
+const (
+	DefaultMinBackoff      = 500 * time.Millisecond
+	DefaultMaxBackoff      = 30 * time.Second
+	DefaultMaxRestarts     = 5
+	DefaultShutdownTimeout = 5 * time.Second
+	DefaultInitTimeout     = 30 * time.Second
+)
+
+// stableAfter is how long a process must run before its exit is no longer
+// counted as a crash loop.
+const stableAfter = time.Minute
+
+// ErrServerStopped is returned when a supervised server has been closed or
+// has failed too many times to be restarted.
+var ErrServerStopped = errors.New("mcp server is not running")
+
+// Supervisor runs an MCP server subprocess over stdio and keeps it
+// running. The server's stderr is copied line by line into the log with
+// the server name as prefix. When the process exits unexpectedly the
+// pending calls fail, and the server is restarted with exponential
+// backoff and a freshly initialised client. Supervisor implements
+// Toolbox, waiting for a restart in progress before each call.
+type Supervisor struct {
+	Name string
+	// NewCmd returns the command to run. It is called on every start, as
+	// an exec.Cmd cannot be reused.
+	NewCmd func() *exec.Cmd
+	// Wrap, if set, wraps the stdio transport of each new process, for
+	// example to record the traffic.
+	Wrap func(transport.Transport) transport.Transport
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxRestarts is the number of consecutive crashes or failed restarts
+	// after which the supervisor gives up.
+	MaxRestarts int
+	// ShutdownTimeout is how long Close waits after closing stdin, and
+	// again after SIGTERM, before escalating.
+	ShutdownTimeout time.Duration
+	// InitTimeout bounds the initialisation of each new process.
+	InitTimeout time.Duration
+
+	mu     sync.Mutex
+	proc   *process
+	ready  chan struct{}
+	err    error
+	closed bool
+	stop   chan struct{}
+}
+
+// process is a single run of the server.
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.Closer
+	transport transport.Transport
+	bridge    *Bridge
+	started   time.Time
+	exited    chan struct{}
+	exitErr   error
+}
+
+// NewSupervisor returns a Supervisor for the server started by newCmd,
+// with default backoff and timeouts.
+func NewSupervisor(name string, newCmd func() *exec.Cmd) *Supervisor {
+	return &Supervisor{
+		Name:            name,
+		NewCmd:          newCmd,
+		MinBackoff:      DefaultMinBackoff,
+		MaxBackoff:      DefaultMaxBackoff,
+		MaxRestarts:     DefaultMaxRestarts,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		InitTimeout:     DefaultInitTimeout,
+	}
+}
+
+// Start starts and initialises the server, then supervises it in the
+// background until Close.
+func (s *Supervisor) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("server %s already started", s.Name)
+	}
+	s.stop = make(chan struct{})
+	s.ready = make(chan struct{})
+	s.mu.Unlock()
+
+	p, err := s.launch(ctx)
+	if err != nil {
+		return err
+	}
+	s.setProcess(p)
+	go s.watch(p)
+	return nil
+}
+
+// Client returns the client of the running process, or nil while the
+// server is restarting.
+func (s *Supervisor) Client() *mcp_golang.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc == nil {
+		return nil
+	}
+	return s.proc.bridge.Client()
+}
+
+// ListTools lists the tools of the server.
+func (s *Supervisor) ListTools(ctx context.Context) ([]mcp_golang.ToolRetType, error) {
+	b, err := s.bridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.ListTools(ctx)
+}
+
+// CallTool calls a tool on the server, waiting for it to come back if it
+// is restarting.
+func (s *Supervisor) CallTool(ctx context.Context, name string, args map[string]any) map[string]any {
+	b, err := s.bridge(ctx)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return b.CallTool(ctx, name, args)
+}
+
+// bridge returns the bridge of the running process, waiting for a
+// restart in progress.
+func (s *Supervisor) bridge(ctx context.Context) (*Bridge, error) {
+	for {
+		s.mu.Lock()
+		if s.err != nil {
+			err := s.err
+			s.mu.Unlock()
+			return nil, err
+		}
+		if s.proc != nil {
+			b := s.proc.bridge
+			s.mu.Unlock()
+			return b, nil
+		}
+		ready := s.ready
+		s.mu.Unlock()
+
+		if ready == nil {
+			return nil, fmt.Errorf("server %s: %w", s.Name, ErrServerStopped)
+		}
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("server %s is restarting: %w", s.Name, ctx.Err())
+		}
+	}
+}
+
+func (s *Supervisor) setProcess(p *process) {
+	s.mu.Lock()
+	s.proc = p
+	close(s.ready)
+	s.mu.Unlock()
+}
+
+// launch starts a new process and initialises its client.
+func (s *Supervisor) launch(ctx context.Context) (*process, error) {
+	cmd := s.NewCmd()
+	setProcessGroup(cmd)
+	cmd.Stderr = &lineLogger{prefix: "[" + s.Name + "] "}
+	cmd.WaitDelay = s.shutdownTimeout()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start server %s: %w", s.Name, err)
+	}
+
+	var t transport.Transport = stdio.NewStdioServerTransportWithIO(stdout, stdin)
+	if s.Wrap != nil {
+		t = s.Wrap(t)
+	}
+	p := &process{
+		cmd:       cmd,
+		stdin:     stdin,
+		transport: t,
+		started:   time.Now(),
+		exited:    make(chan struct{}),
+	}
+	go func() {
+		p.exitErr = cmd.Wait()
+		close(p.exited)
+		// Fail the requests still waiting for this process.
+		p.transport.Close()
+	}()
+
+	initTimeout := s.InitTimeout
+	if initTimeout <= 0 {
+		initTimeout = DefaultInitTimeout
+	}
+	initCtx, cancel := context.WithTimeout(ctx, initTimeout)
+	defer cancel()
+
+	bridge, err := Connect(initCtx, t)
+	if err != nil {
+		killProcess(cmd.Process)
+		<-p.exited
+		return nil, fmt.Errorf("server %s: %w", s.Name, err)
+	}
+	p.bridge = bridge
+	return p, nil
+}
+
+// watch restarts the server whenever p exits, until Close is called or
+// too many restarts fail in a row.
+func (s *Supervisor) watch(p *process) {
+	crashes := 0
+	for {
+		select {
+		case <-p.exited:
+		case <-s.stop:
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		s.proc = nil
+		s.ready = make(chan struct{})
+		s.mu.Unlock()
+
+		uptime := time.Since(p.started)
+		log.Printf("server %s exited after %s: %v", s.Name, uptime.Round(time.Millisecond), exitReason(p.exitErr))
+		if uptime < stableAfter {
+			crashes++
+		} else {
+			crashes = 0
+		}
+
+		next, failures, err := s.restart(crashes)
+		crashes += failures
+		if err != nil {
+			s.mu.Lock()
+			s.err = err
+			close(s.ready)
+			s.mu.Unlock()
+			log.Print(err)
+			return
+		}
+		if next == nil {
+			return
+		}
+
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			s.shutdown(next)
+			return
+		}
+		s.setProcess(next)
+		p = next
+	}
+}
+
+// restart launches a new process, backing off exponentially with the
+// number of crashes so far, and reports how many attempts failed. It
+// returns a nil process without error if the supervisor is closed
+// meanwhile.
+func (s *Supervisor) restart(crashes int) (*process, int, error) {
+	minBackoff := s.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	maxBackoff := s.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	maxRestarts := s.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = DefaultMaxRestarts
+	}
+
+	failures := 0
+	for crashes+failures <= maxRestarts {
+		backoff := maxBackoff
+		if n := crashes + failures - 1; n < 20 {
+			backoff = min(minBackoff<<max(n, 0), maxBackoff)
+		}
+		log.Printf("server %s: restarting in %s", s.Name, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.stop:
+			return nil, failures, nil
+		}
+
+		p, err := s.launch(context.Background())
+		if err == nil {
+			return p, failures, nil
+		}
+		log.Printf("server %s: restart failed: %v", s.Name, err)
+		failures++
+	}
+	return nil, failures, fmt.Errorf("server %s: giving up after %d restarts: %w", s.Name, maxRestarts, ErrServerStopped)
+}
+
+// Close shuts the server down: it closes its stdin, which a well-behaved
+// server takes as the end of the session, then sends SIGTERM and finally
+// SIGKILL if the process is still running after ShutdownTimeout.
+func (s *Supervisor) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	if s.stop != nil {
+		close(s.stop)
+	}
+	p := s.proc
+	s.proc = nil
+	s.err = fmt.Errorf("server %s: %w", s.Name, ErrServerStopped)
+	s.mu.Unlock()
+
+	if p == nil {
+		return nil
+	}
+	return s.shutdown(p)
+}
+
+// shutdown stops p, escalating from closing stdin to SIGTERM to SIGKILL.
+func (s *Supervisor) shutdown(p *process) error {
+	timeout := s.shutdownTimeout()
+	p.stdin.Close()
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	log.Printf("server %s did not exit after closing stdin, sending SIGTERM", s.Name)
+	if err := terminateProcess(p.cmd.Process); err != nil {
+		log.Printf("server %s: %v", s.Name, err)
+	}
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	log.Printf("server %s did not exit after SIGTERM, sending SIGKILL", s.Name)
+	if err := killProcess(p.cmd.Process); err != nil {
+		return fmt.Errorf("failed to kill server %s: %w", s.Name, err)
+	}
+	<-p.exited
+	return nil
+}
+
+func (s *Supervisor) shutdownTimeout() time.Duration {
+	if s.ShutdownTimeout <= 0 {
+		return DefaultShutdownTimeout
+	}
+	return s.ShutdownTimeout
+}
+
+func exitReason(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
+
+// lineLogger writes every complete line to the log with a prefix.
+type lineLogger struct {
+	prefix string
+	buf    []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		log.Print(l.prefix + string(bytes.TrimRight(l.buf[:i], "\r")))
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// CloseOnSignal closes c and exits when the program is interrupted or
+// terminated. Supervised servers run in their own process group and would
+// otherwise outlive an interrupted client.
+func CloseOnSignal(c io.Closer) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		c.Close()
+		os.Exit(1)
+	}()
+}
@@ -0,0 +1,189 @@
+package price
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is how long prices are cached when the config does not
+// say.
+const DefaultCacheTTL = 30 * time.Second
+
+// DefaultPrefetch are the currencies a cache fetches along with any
+// lookup, so that asking for one currency after another costs a single
+// request.
+var DefaultPrefetch = []string{"usd", "eur", "gbp", "jpy", "aud", "cad", "chf", "cny", "krw", "rub"}
+
+// Cache keeps the prices returned by sources for TTL, keyed by source and
+// asset. Concurrent lookups of the same prices share a single request.
+type Cache struct {
+	TTL time.Duration
+	// Prefetch are currencies requested along with those asked for.
+	Prefetch []string
+
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+	group   singleflight.Group
+	// onWait, if set, is called when a lookup starts waiting for a
+	// request, so tests know when every lookup has joined it.
+	onWait func()
+}
+
+type cacheKey struct {
+	source string
+	asset  string
+}
+
+type cacheEntry struct {
+	prices    map[string]float64
+	fetchedAt time.Time
+	cachedAt  time.Time
+}
+
+// NewCache returns an empty cache keeping prices for ttl.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{
+		TTL:      ttl,
+		Prefetch: DefaultPrefetch,
+		entries:  map[cacheKey]*cacheEntry{},
+	}
+}
+
+// Wrap returns s with its prices cached.
+func (c *Cache) Wrap(s Source) Source {
+	return &cachedSource{cache: c, source: s}
+}
+
+type cachedSource struct {
+	cache  *Cache
+	source Source
+}
+
+func (s *cachedSource) Name() string { return s.source.Name() }
+
+// Prices serves the assets whose prices are all cached and fresh from the
+// cache, and fetches the others in one request. CachedAt is set when any
+// price comes from the cache.
+func (s *cachedSource) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	c := s.cache
+	q := &Quotes{Source: s.Name(), Prices: map[string]map[string]float64{}}
+	var missing []string
+
+	c.mu.Lock()
+	now := time.Now()
+	for _, asset := range assets {
+		e := c.entries[cacheKey{s.Name(), asset}]
+		if e == nil || now.Sub(e.cachedAt) > c.TTL || !e.has(currencies) {
+			missing = append(missing, asset)
+			continue
+		}
+		prices := map[string]float64{}
+		for _, currency := range currencies {
+			prices[currency] = e.prices[currency]
+		}
+		q.Prices[asset] = prices
+		q.FetchedAt = oldest(q.FetchedAt, e.fetchedAt)
+		q.CachedAt = oldest(q.CachedAt, e.cachedAt)
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return q, nil
+	}
+
+	fetched, err := s.fetch(ctx, missing, currencies)
+	if err != nil {
+		return nil, err
+	}
+	for _, asset := range missing {
+		prices := map[string]float64{}
+		for _, currency := range currencies {
+			if p, ok := fetched.Price(asset, currency); ok {
+				prices[currency] = p
+			}
+		}
+		q.Prices[asset] = prices
+	}
+	q.FetchedAt = oldest(q.FetchedAt, fetched.FetchedAt)
+	return q, nil
+}
+
+// fetch requests the prices of assets in currencies and the prefetched
+// currencies, sharing the request with identical concurrent lookups.
+func (s *cachedSource) fetch(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	c := s.cache
+	assets = sortedUnion(assets)
+	currencies = sortedUnion(currencies, c.Prefetch)
+	key := s.Name() + "|" + strings.Join(assets, ",") + "|" + strings.Join(currencies, ",")
+
+	// The request outlives a caller that gives up, as others may wait
+	// for it.
+	ch := c.group.DoChan(key, func() (any, error) {
+		q, err := s.source.Prices(context.WithoutCancel(ctx), assets, currencies)
+		if err != nil {
+			return nil, err
+		}
+		c.store(s.Name(), q)
+		return q, nil
+	})
+	if c.onWait != nil {
+		c.onWait()
+	}
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Quotes), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// store caches the prices of q and drops expired entries.
+func (c *Cache) store(source string, q *Quotes) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, e := range c.entries {
+		if now.Sub(e.cachedAt) > c.TTL {
+			delete(c.entries, key)
+		}
+	}
+	for asset, prices := range q.Prices {
+		if len(prices) > 0 {
+			c.entries[cacheKey{source, asset}] = &cacheEntry{prices: prices, fetchedAt: q.FetchedAt, cachedAt: now}
+		}
+	}
+}
+
+func (e *cacheEntry) has(currencies []string) bool {
+	for _, currency := range currencies {
+		if _, ok := e.prices[currency]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedUnion returns the sorted distinct values of lists.
+func sortedUnion(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	slices.Sort(all)
+	return slices.Compact(all)
+}
+
+// oldest returns the earlier of two times, ignoring zero times.
+func oldest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,214 @@
+package price
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSource prices every asset at 100 in every currency and records
+// the lookups it gets. If release is set, lookups wait for it to close.
+type countingSource struct {
+	release chan struct{}
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (s *countingSource) Name() string { return "counting" }
+
+func (s *countingSource) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, slices.Clone(assets))
+	s.mu.Unlock()
+	if s.release != nil {
+		<-s.release
+	}
+
+	q := &Quotes{Source: s.Name(), Prices: map[string]map[string]float64{}, FetchedAt: time.Now()}
+	for _, asset := range assets {
+		q.Prices[asset] = map[string]float64{}
+		for _, currency := range currencies {
+			q.Prices[asset][currency] = 100
+		}
+	}
+	return q, nil
+}
+
+func (s *countingSource) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.calls)
+}
+
+func TestCachePrefetch(t *testing.T) {
+	source := &countingSource{}
+	cache := NewCache(time.Minute)
+	cached := cache.Wrap(source)
+	ctx := context.Background()
+
+	eur, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"eur"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := eur.Price("bitcoin", "eur"); !ok || p != 100 {
+		t.Errorf("eur price = %v, %t", p, ok)
+	}
+	if !eur.CachedAt.IsZero() {
+		t.Errorf("CachedAt = %v on a miss, want zero", eur.CachedAt)
+	}
+	if _, ok := eur.Price("bitcoin", "gbp"); ok {
+		t.Error("prefetched currency leaked into the answer")
+	}
+
+	gbp, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"gbp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := source.count(); n != 1 {
+		t.Errorf("EUR then GBP made %d requests, want 1", n)
+	}
+	if gbp.CachedAt.IsZero() {
+		t.Error("CachedAt not set on a hit")
+	}
+	if !gbp.FetchedAt.Equal(eur.FetchedAt) {
+		t.Errorf("FetchedAt = %v on a hit, want the time of the fetch %v", gbp.FetchedAt, eur.FetchedAt)
+	}
+
+	// A currency that is not prefetched is a miss.
+	if _, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"btc"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := source.count(); n != 2 {
+		t.Errorf("got %d requests after a currency that is not prefetched, want 2", n)
+	}
+}
+
+func TestCacheFetchesOnlyMissingAssets(t *testing.T) {
+	source := &countingSource{}
+	cached := NewCache(time.Minute).Wrap(source)
+	ctx := context.Background()
+
+	if _, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"usd"}); err != nil {
+		t.Fatal(err)
+	}
+	q, err := cached.Prices(ctx, []string{"bitcoin", "ethereum"}, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.Price("ethereum", "usd"); !ok {
+		t.Error("no ethereum price")
+	}
+	if got := source.calls[1]; !slices.Equal(got, []string{"ethereum"}) {
+		t.Errorf("second request asked for %v, want only ethereum", got)
+	}
+	if q.CachedAt.IsZero() {
+		t.Error("CachedAt not set although bitcoin came from the cache")
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	source := &countingSource{}
+	cache := NewCache(time.Minute)
+	cached := cache.Wrap(source)
+	ctx := context.Background()
+
+	if _, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"usd"}); err != nil {
+		t.Fatal(err)
+	}
+	cache.mu.Lock()
+	cache.entries[cacheKey{"counting", "bitcoin"}].cachedAt = time.Now().Add(-2 * time.Minute)
+	cache.mu.Unlock()
+
+	q, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := source.count(); n != 2 {
+		t.Errorf("got %d requests after the TTL, want 2", n)
+	}
+	if !q.CachedAt.IsZero() {
+		t.Errorf("CachedAt = %v for an expired entry, want a fresh fetch", q.CachedAt)
+	}
+}
+
+func TestCacheCoalescesConcurrentLookups(t *testing.T) {
+	source := &countingSource{release: make(chan struct{})}
+	cache := NewCache(time.Minute)
+	cached := cache.Wrap(source)
+	ctx := context.Background()
+
+	const lookups = 10
+	var waiting sync.WaitGroup
+	waiting.Add(lookups)
+	cache.onWait = waiting.Done
+	var wg sync.WaitGroup
+	errs := make(chan error, lookups)
+	for range lookups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"eur"})
+			if err == nil {
+				if _, ok := q.Price("bitcoin", "eur"); !ok {
+					t.Error("no bitcoin price")
+				}
+			}
+			errs <- err
+		}()
+	}
+	// Every lookup joins the request in flight before it completes.
+	waiting.Wait()
+	close(source.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := source.count(); n != 1 {
+		t.Errorf("%d concurrent lookups made %d requests, want 1", lookups, n)
+	}
+}
+
+func TestCacheCallerGivesUp(t *testing.T) {
+	source := &countingSource{release: make(chan struct{})}
+	cache := NewCache(time.Minute)
+	cached := cache.Wrap(source)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cached.Prices(ctx, []string{"bitcoin"}, []string{"usd"}); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want the deadline", err)
+	}
+
+	// The request carries on and caches its prices for the next caller.
+	close(source.release)
+	for !cache.cached("counting", "bitcoin") {
+		time.Sleep(time.Millisecond)
+	}
+	q, err := cached.Prices(context.Background(), []string{"bitcoin"}, []string{"usd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.Price("bitcoin", "usd"); !ok {
+		t.Error("no bitcoin price")
+	}
+	if q.CachedAt.IsZero() {
+		t.Error("CachedAt not set, want the prices of the abandoned request")
+	}
+	if n := source.count(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+// cached reports whether the cache holds prices of asset from source.
+func (c *Cache) cached(source, asset string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[cacheKey{source, asset}] != nil
+}
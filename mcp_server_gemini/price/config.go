@@ -0,0 +1,211 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"example.com/mcp-server/upstream"
+)
+
+// SourceConfig describes a single price source.
+type SourceConfig struct {
+	// Type is coingecko, coinbase, cryptocompare, file or csv. Coinbase
+	// and file sources only have current prices, csv sources only past
+	// prices.
+	Type string `json:"type"`
+	// URL replaces the base URL of an API source, e.g. to point it at a
+	// mock server or at a service with the same API.
+	URL string `json:"url,omitempty"`
+	// Headers are sent with every request of an API source. Values are
+	// expanded with environment variables, so keys stay out of the file.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Path is the price file of a file source.
+	Path string `json:"path,omitempty"`
+	// Dir is the directory of the price history files of a csv source.
+	Dir string `json:"dir,omitempty"`
+	// RatePerMinute and Burst size the token bucket of an API source.
+	// Zero uses DefaultRatePerMinute and DefaultBurst, a negative rate
+	// lifts the limit.
+	RatePerMinute float64 `json:"rate_per_minute,omitempty"`
+	Burst         int     `json:"burst,omitempty"`
+}
+
+const (
+	DefaultRatePerMinute = 30
+	DefaultBurst         = 5
+)
+
+// Config lists the price sources in the order they are tried.
+type Config struct {
+	Sources []SourceConfig `json:"sources"`
+	// CacheTTL is how long the current prices of API sources are cached,
+	// such as "1m". It defaults to DefaultCacheTTL, and "0" disables the
+	// cache.
+	CacheTTL string `json:"cache_ttl,omitempty"`
+
+	once  sync.Once
+	built []any
+	err   error
+}
+
+// DefaultConfig uses the public CoinGecko, Coinbase and CryptoCompare
+// APIs, in that order, and CoinGecko and CryptoCompare for past prices.
+func DefaultConfig() *Config {
+	return &Config{Sources: []SourceConfig{
+		{Type: "coingecko"},
+		{Type: "coinbase"},
+		{Type: "cryptocompare"},
+	}}
+}
+
+// LoadConfig reads and validates a price source config file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading price config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing price config %s: %w", path, err)
+	}
+	if len(cfg.Sources) == 0 {
+		return nil, fmt.Errorf("price config %s lists no sources", path)
+	}
+	if _, err := cfg.cacheTTL(); err != nil {
+		return nil, fmt.Errorf("price config %s: %w", path, err)
+	}
+	if _, err := cfg.Source(); err != nil {
+		return nil, fmt.Errorf("price config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Source builds the configured sources of current prices, failing over
+// from one to the next. API sources share a cache.
+func (c *Config) Source() (Source, error) {
+	ttl, err := c.cacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	cache := NewCache(ttl)
+
+	built, err := c.build()
+	if err != nil {
+		return nil, err
+	}
+	f := &Failover{}
+	for _, s := range built {
+		switch s := s.(type) {
+		case *File:
+			// Read on every call, so edits show at once.
+			f.Sources = append(f.Sources, s)
+		case Source:
+			if ttl > 0 {
+				s = cache.Wrap(s)
+			}
+			f.Sources = append(f.Sources, s)
+		}
+	}
+	return f, nil
+}
+
+func (c *Config) cacheTTL() (time.Duration, error) {
+	if c.CacheTTL == "" {
+		return DefaultCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(c.CacheTTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache_ttl: %w", err)
+	}
+	return ttl, nil
+}
+
+// History builds the configured sources of past prices, failing over
+// from one to the next.
+func (c *Config) History() (History, error) {
+	built, err := c.build()
+	if err != nil {
+		return nil, err
+	}
+	f := &HistoryFailover{}
+	for _, s := range built {
+		if h, ok := s.(History); ok {
+			f.Sources = append(f.Sources, h)
+		}
+	}
+	return f, nil
+}
+
+// build builds the sources once, so that Source and History share the
+// upstream client, and with it the rate limit and circuit breaker, of an
+// API source.
+func (c *Config) build() ([]any, error) {
+	c.once.Do(func() {
+		for i, sc := range c.Sources {
+			s, err := sc.build()
+			if err != nil {
+				c.err = fmt.Errorf("source %d: %w", i, err)
+				return
+			}
+			c.built = append(c.built, s)
+		}
+	})
+	return c.built, c.err
+}
+
+// build returns a Source, a History or both.
+func (sc SourceConfig) build() (any, error) {
+	switch sc.Type {
+	case "coingecko":
+		return &CoinGecko{BaseURL: sc.URL, Header: sc.header(), Client: sc.client()}, nil
+	case "coinbase":
+		return &Coinbase{BaseURL: sc.URL, Header: sc.header(), Client: sc.client()}, nil
+	case "cryptocompare":
+		return &CryptoCompare{BaseURL: sc.URL, Header: sc.header(), Client: sc.client()}, nil
+	case "file":
+		if sc.Path == "" {
+			return nil, fmt.Errorf("file source needs a path")
+		}
+		return &File{Path: sc.Path}, nil
+	case "csv":
+		if sc.Dir == "" {
+			return nil, fmt.Errorf("csv source needs a dir")
+		}
+		return &CSV{Dir: sc.Dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown source type %q", sc.Type)
+	}
+}
+
+// header returns the configured headers with environment variables
+// expanded.
+func (sc SourceConfig) header() http.Header {
+	header := http.Header{}
+	for key, value := range sc.Headers {
+		header.Set(key, os.ExpandEnv(value))
+	}
+	return header
+}
+
+// client returns the upstream client of an HTTP source, rate limited
+// unless RatePerMinute is negative.
+func (sc SourceConfig) client() *upstream.Client {
+	client := upstream.New(sc.Type)
+	if sc.RatePerMinute >= 0 {
+		perMinute, burst := sc.RatePerMinute, sc.Burst
+		if perMinute == 0 {
+			perMinute = DefaultRatePerMinute
+		}
+		if burst <= 0 {
+			burst = DefaultBurst
+		}
+		client.Limiter = rate.NewLimiter(rate.Limit(perMinute/60), burst)
+	}
+	return client
+}
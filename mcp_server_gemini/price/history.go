@@ -0,0 +1,319 @@
+package price
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of the dates taken by the history tools.
+const DateLayout = "2006-01-02"
+
+// Candle is the open, high, low and close price of a single UTC day.
+type Candle struct {
+	Date  time.Time `json:"date"`
+	Open  float64   `json:"open"`
+	High  float64   `json:"high"`
+	Low   float64   `json:"low"`
+	Close float64   `json:"close"`
+}
+
+// check fails for a candle whose high and low do not bound its open and
+// close.
+func (c Candle) check() error {
+	if c.Low > min(c.Open, c.Close) {
+		return fmt.Errorf("low %g is above the open or close", c.Low)
+	}
+	if c.High < max(c.Open, c.Close) {
+		return fmt.Errorf("high %g is below the open or close", c.High)
+	}
+	return nil
+}
+
+// Series are the candles returned by a history source.
+type Series struct {
+	// Source is the name of the source that returned the candles.
+	Source  string   `json:"source"`
+	Candles []Candle `json:"candles"`
+}
+
+// History is a source of past prices.
+type History interface {
+	// Name identifies the source in errors and results.
+	Name() string
+	// Candles returns the daily candles of asset in currency from the
+	// day of from to the day of to, inclusive, oldest first.
+	Candles(ctx context.Context, asset, currency string, from, to time.Time) (*Series, error)
+}
+
+// Stats summarise a series of candles.
+type Stats struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Open is the open of the first day and Close the close of the last.
+	Open  float64 `json:"open"`
+	Close float64 `json:"close"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	// Mean is the mean of the daily closes.
+	Mean          float64 `json:"mean"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// Summarize computes the stats of candles, which must not be empty.
+func Summarize(candles []Candle) Stats {
+	first, last := candles[0], candles[len(candles)-1]
+	s := Stats{
+		From:  first.Date,
+		To:    last.Date,
+		Open:  first.Open,
+		Close: last.Close,
+		Min:   math.Inf(1),
+		Max:   math.Inf(-1),
+	}
+	var sum float64
+	for _, c := range candles {
+		s.Min = math.Min(s.Min, c.Low)
+		s.Max = math.Max(s.Max, c.High)
+		sum += c.Close
+	}
+	s.Mean = sum / float64(len(candles))
+	s.Change = s.Close - s.Open
+	if s.Open != 0 {
+		s.ChangePercent = s.Change / s.Open * 100
+	}
+	return s
+}
+
+// HistoryFailover tries its sources in order and returns the candles of
+// the first one that has any.
+type HistoryFailover struct {
+	Sources []History
+}
+
+func (f *HistoryFailover) Name() string {
+	names := make([]string, len(f.Sources))
+	for i, s := range f.Sources {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *HistoryFailover) Candles(ctx context.Context, asset, currency string, from, to time.Time) (*Series, error) {
+	if len(f.Sources) == 0 {
+		return nil, errors.New("no history sources configured")
+	}
+	var errs []error
+	for i, s := range f.Sources {
+		series, err := s.Candles(ctx, asset, currency, from, to)
+		if err == nil && len(series.Candles) == 0 {
+			err = fmt.Errorf("no prices for %s in %s from %s to %s", asset, currency, from.Format(DateLayout), to.Format(DateLayout))
+		}
+		if err == nil {
+			return series, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		if ctx.Err() != nil || i == len(f.Sources)-1 {
+			break
+		}
+		log.Printf("history source %s failed, trying the next one: %v", s.Name(), err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// day truncates t to the start of its UTC day.
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// Candles builds daily candles from the market chart of CoinGecko, which
+// returns prices every few minutes for a day, hourly up to 90 days and
+// daily beyond.
+func (s *CoinGecko) Candles(ctx context.Context, asset, currency string, from, to time.Time) (*Series, error) {
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultCoinGeckoURL
+	}
+	from, to = day(from), day(to).Add(24*time.Hour-time.Second)
+	query := url.Values{
+		"vs_currency": {currency},
+		"from":        {strconv.FormatInt(from.Unix(), 10)},
+		"to":          {strconv.FormatInt(to.Unix(), 10)},
+	}
+
+	var chart struct {
+		Prices [][2]float64 `json:"prices"`
+	}
+	if err := getJSON(ctx, s.Client, s.Name(), base+"/coins/"+url.PathEscape(asset)+"/market_chart/range?"+query.Encode(), s.Header, &chart); err != nil {
+		return nil, err
+	}
+
+	var candles []Candle
+	for _, point := range chart.Prices {
+		date := day(time.UnixMilli(int64(point[0])))
+		p := point[1]
+		if n := len(candles); n > 0 && candles[n-1].Date.Equal(date) {
+			c := &candles[n-1]
+			c.High = math.Max(c.High, p)
+			c.Low = math.Min(c.Low, p)
+			c.Close = p
+			continue
+		}
+		candles = append(candles, Candle{Date: date, Open: p, High: p, Low: p, Close: p})
+	}
+	return &Series{Source: s.Name(), Candles: candles}, nil
+}
+
+// Candles reads the daily candles of CryptoCompare.
+func (s *CryptoCompare) Candles(ctx context.Context, asset, currency string, from, to time.Time) (*Series, error) {
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultCryptoCompareURL
+	}
+	from, to = day(from), day(to)
+	query := url.Values{
+		"fsym":  {Symbol(asset)},
+		"tsym":  {strings.ToUpper(currency)},
+		"limit": {strconv.Itoa(int(to.Sub(from).Hours() / 24))},
+		"toTs":  {strconv.FormatInt(to.Unix(), 10)},
+	}
+
+	var resp struct {
+		Response string
+		Message  string
+		Data     struct {
+			Data []struct {
+				Time  int64   `json:"time"`
+				Open  float64 `json:"open"`
+				High  float64 `json:"high"`
+				Low   float64 `json:"low"`
+				Close float64 `json:"close"`
+			}
+		}
+	}
+	if err := getJSON(ctx, s.Client, s.Name(), base+"/v2/histoday?"+query.Encode(), s.Header, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Response == "Error" {
+		return nil, fmt.Errorf("cryptocompare error: %s", resp.Message)
+	}
+
+	var candles []Candle
+	for _, d := range resp.Data.Data {
+		date := time.Unix(d.Time, 0).UTC()
+		// Days before the asset existed come back as zeros.
+		if date.Before(from) || date.After(to) || d.Close == 0 {
+			continue
+		}
+		candles = append(candles, Candle{Date: date, Open: d.Open, High: d.High, Low: d.Low, Close: d.Close})
+	}
+	return &Series{Source: s.Name(), Candles: candles}, nil
+}
+
+// CSV reads past prices from CSV files in Dir named after the asset and
+// currency, such as bitcoin-usd.csv or bitcoin-usd-2024.csv, with the
+// columns date, open, high, low and close.
+type CSV struct {
+	Dir string
+}
+
+func (s *CSV) Name() string { return "csv:" + s.Dir }
+
+func (s *CSV) Candles(ctx context.Context, asset, currency string, from, to time.Time) (*Series, error) {
+	from, to = day(from), day(to)
+	name := asset + "-" + currency
+	paths, err := filepath.Glob(filepath.Join(s.Dir, name+"-*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, name+".csv")); err == nil {
+		paths = append(paths, filepath.Join(s.Dir, name+".csv"))
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no price file for %s in %s", asset, currency)
+	}
+
+	byDate := map[time.Time]Candle{}
+	for _, path := range paths {
+		candles, err := readCandles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candles {
+			if !c.Date.Before(from) && !c.Date.After(to) {
+				byDate[c.Date] = c
+			}
+		}
+	}
+
+	candles := make([]Candle, 0, len(byDate))
+	for _, c := range byDate {
+		candles = append(candles, c)
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Date.Before(candles[j].Date) })
+	return &Series{Source: s.Name(), Candles: candles}, nil
+}
+
+// readCandles reads a CSV file of candles with a header row.
+func readCandles(path string) ([]Candle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "open", "high", "low", "close"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s has no %s column", path, name)
+		}
+	}
+
+	var candles []Candle
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return candles, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+
+		date, err := time.Parse(DateLayout, record[columns["date"]])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date: %w", path, line, err)
+		}
+		c := Candle{Date: date}
+		for name, v := range map[string]*float64{"open": &c.Open, "high": &c.High, "low": &c.Low, "close": &c.Close} {
+			*v, err = strconv.ParseFloat(record[columns[name]], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid %s: %w", path, line, name, err)
+			}
+		}
+		if err := c.check(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		candles = append(candles, c)
+	}
+}
@@ -0,0 +1,52 @@
+package price
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCandles(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     string
+		wantErr string
+	}{
+		{name: "valid", row: "2024-01-01,100,110,90,105"},
+		{name: "flat", row: "2024-01-01,100,100,100,100"},
+		{name: "high below close", row: "2024-01-01,100,104,90,105", wantErr: ":2: high 104 is below the open or close"},
+		{name: "high below open", row: "2024-01-01,100,99,90,95", wantErr: "high 99 is below"},
+		{name: "low above open", row: "2024-01-01,100,110,101,105", wantErr: ":2: low 101 is above the open or close"},
+		{name: "low above close", row: "2024-01-01,100,110,96,95", wantErr: "low 96 is above"},
+		{name: "bad number", row: "2024-01-01,100,x,90,105", wantErr: "invalid high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bitcoin-usd.csv")
+			if err := os.WriteFile(path, []byte("date,open,high,low,close\n"+tt.row+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			candles, err := readCandles(path)
+			if tt.wantErr == "" {
+				if err != nil || len(candles) != 1 {
+					t.Fatalf("readCandles = %v, %v, want one candle", candles, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSampleCandles(t *testing.T) {
+	candles, err := readCandles(filepath.Join("..", "server", "data", "bitcoin-usd-sample.csv"))
+	if err != nil {
+		t.Fatalf("sample history: %v", err)
+	}
+	if len(candles) != 7 {
+		t.Errorf("got %d candles, want 7", len(candles))
+	}
+}
@@ -0,0 +1,165 @@
+// Package price fetches cryptocurrency prices from interchangeable
+// sources: market data APIs such as CoinGecko, exchanges, or a local file.
+// Sources are chosen by a JSON config and tried in order, failing over to
+// the next one when a source errors.
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/mcp-server/upstream"
+)
+
+// Source is a source of cryptocurrency prices.
+type Source interface {
+	// Name identifies the source in errors and results.
+	Name() string
+	// Prices returns the price of every asset in every currency. Assets
+	// are CoinGecko coin IDs such as "bitcoin", currencies are lower case
+	// codes such as "usd".
+	Prices(ctx context.Context, assets, currencies []string) (*Quotes, error)
+}
+
+// Quotes are the prices returned by a source.
+type Quotes struct {
+	// Source is the name of the source that returned the prices.
+	Source string `json:"source"`
+	// Prices maps an asset and a currency to a price.
+	Prices    map[string]map[string]float64 `json:"prices"`
+	FetchedAt time.Time                     `json:"fetched_at"`
+	// CachedAt is when the prices were cached, if they come from a
+	// Cache rather than from the source itself.
+	CachedAt time.Time `json:"cached_at,omitzero"`
+}
+
+// Price returns the price of asset in currency.
+func (q *Quotes) Price(asset, currency string) (float64, bool) {
+	price, ok := q.Prices[asset][currency]
+	return price, ok
+}
+
+// count returns the number of prices in q.
+func (q *Quotes) count() int {
+	n := 0
+	for _, prices := range q.Prices {
+		n += len(prices)
+	}
+	return n
+}
+
+// missing returns an error naming the first requested price that q lacks.
+func (q *Quotes) missing(assets, currencies []string) error {
+	for _, asset := range assets {
+		for _, currency := range currencies {
+			if _, ok := q.Price(asset, currency); !ok {
+				return fmt.Errorf("no price for %s in %s", asset, currency)
+			}
+		}
+	}
+	return nil
+}
+
+// Failover tries its sources in order and returns the prices of the
+// first one that has all of them. When every source lacks some prices,
+// the most complete answer is returned.
+type Failover struct {
+	Sources []Source
+}
+
+func (f *Failover) Name() string {
+	names := make([]string, len(f.Sources))
+	for i, s := range f.Sources {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *Failover) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	if len(f.Sources) == 0 {
+		return nil, errors.New("no price sources configured")
+	}
+	var errs []error
+	var best *Quotes
+	for i, s := range f.Sources {
+		q, err := s.Prices(ctx, assets, currencies)
+		if err == nil {
+			if best == nil || q.count() > best.count() {
+				best = q
+			}
+			err = q.missing(assets, currencies)
+		}
+		if err == nil {
+			return q, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		if ctx.Err() != nil || i == len(f.Sources)-1 {
+			break
+		}
+		log.Printf("price source %s failed, trying the next one: %v", s.Name(), err)
+	}
+	if best != nil && best.count() > 0 {
+		return best, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// symbols maps CoinGecko coin IDs to the ticker symbols used by exchanges.
+var symbols = map[string]string{
+	"bitcoin":      "BTC",
+	"ethereum":     "ETH",
+	"tether":       "USDT",
+	"binancecoin":  "BNB",
+	"solana":       "SOL",
+	"usd-coin":     "USDC",
+	"ripple":       "XRP",
+	"dogecoin":     "DOGE",
+	"cardano":      "ADA",
+	"tron":         "TRX",
+	"avalanche-2":  "AVAX",
+	"polkadot":     "DOT",
+	"chainlink":    "LINK",
+	"litecoin":     "LTC",
+	"bitcoin-cash": "BCH",
+	"stellar":      "XLM",
+	"monero":       "XMR",
+}
+
+// Symbol returns the ticker symbol of a CoinGecko coin ID. Unknown IDs
+// are assumed to be symbols already.
+func Symbol(asset string) string {
+	if s, ok := symbols[asset]; ok {
+		return s
+	}
+	return strings.ToUpper(asset)
+}
+
+// defaultClients are the upstream clients of the sources that are not
+// given one, by source name, so that sources of one API share a circuit
+// breaker.
+var (
+	defaultMu      sync.Mutex
+	defaultClients = map[string]*upstream.Client{}
+)
+
+// getJSON fetches url with header and decodes the JSON response into v,
+// using client or else the default client of the source name.
+func getJSON(ctx context.Context, client *upstream.Client, name, url string, header http.Header, v any) error {
+	if client == nil {
+		defaultMu.Lock()
+		client = defaultClients[name]
+		if client == nil {
+			client = upstream.New(name)
+			defaultClients[name] = client
+		}
+		defaultMu.Unlock()
+	}
+	return client.GetJSON(ctx, url, header, v)
+}
@@ -0,0 +1,185 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/mcp-server/upstream"
+)
+
+// DefaultCoinGeckoURL is the base URL of the public CoinGecko API.
+const DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3"
+
+// CoinGecko reads prices from the CoinGecko simple price API, or from any
+// service with the same API at BaseURL.
+type CoinGecko struct {
+	BaseURL string
+	Header  http.Header
+	Client  *upstream.Client
+}
+
+func (s *CoinGecko) Name() string { return "coingecko" }
+
+func (s *CoinGecko) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultCoinGeckoURL
+	}
+	query := url.Values{
+		"ids":           {strings.Join(assets, ",")},
+		"vs_currencies": {strings.Join(currencies, ",")},
+	}
+
+	var prices map[string]map[string]float64
+	if err := getJSON(ctx, s.Client, s.Name(), base+"/simple/price?"+query.Encode(), s.Header, &prices); err != nil {
+		return nil, err
+	}
+	return &Quotes{Source: s.Name(), Prices: prices, FetchedAt: time.Now().UTC()}, nil
+}
+
+// DefaultCoinbaseURL is the base URL of the public Coinbase API.
+const DefaultCoinbaseURL = "https://api.coinbase.com/v2"
+
+// Coinbase reads prices from the Coinbase exchange rates API, one request
+// per asset.
+type Coinbase struct {
+	BaseURL string
+	Header  http.Header
+	Client  *upstream.Client
+}
+
+func (s *Coinbase) Name() string { return "coinbase" }
+
+func (s *Coinbase) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultCoinbaseURL
+	}
+
+	q := &Quotes{Source: s.Name(), Prices: map[string]map[string]float64{}}
+	for _, asset := range assets {
+		var resp struct {
+			Data struct {
+				Rates map[string]string `json:"rates"`
+			} `json:"data"`
+		}
+		query := url.Values{"currency": {Symbol(asset)}}
+		if err := getJSON(ctx, s.Client, s.Name(), base+"/exchange-rates?"+query.Encode(), s.Header, &resp); err != nil {
+			return nil, err
+		}
+
+		prices := map[string]float64{}
+		for _, currency := range currencies {
+			rate, ok := resp.Data.Rates[strings.ToUpper(currency)]
+			if !ok {
+				continue
+			}
+			price, err := strconv.ParseFloat(rate, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate %q for %s in %s", rate, asset, currency)
+			}
+			prices[currency] = price
+		}
+		q.Prices[asset] = prices
+	}
+	q.FetchedAt = time.Now().UTC()
+	return q, nil
+}
+
+// DefaultCryptoCompareURL is the base URL of the public CryptoCompare API.
+const DefaultCryptoCompareURL = "https://min-api.cryptocompare.com/data"
+
+// CryptoCompare reads prices from the CryptoCompare aggregate price API.
+// An API key goes in Header as "Authorization: Apikey <key>".
+type CryptoCompare struct {
+	BaseURL string
+	Header  http.Header
+	Client  *upstream.Client
+}
+
+func (s *CryptoCompare) Name() string { return "cryptocompare" }
+
+func (s *CryptoCompare) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultCryptoCompareURL
+	}
+	syms := make([]string, len(assets))
+	for i, asset := range assets {
+		syms[i] = Symbol(asset)
+	}
+	query := url.Values{
+		"fsyms": {strings.Join(syms, ",")},
+		"tsyms": {strings.ToUpper(strings.Join(currencies, ","))},
+	}
+
+	var resp map[string]json.RawMessage
+	if err := getJSON(ctx, s.Client, s.Name(), base+"/pricemulti?"+query.Encode(), s.Header, &resp); err != nil {
+		return nil, err
+	}
+	// Errors come back with status 200 and a Response field.
+	if status, ok := resp["Response"]; ok && string(status) == `"Error"` {
+		var message string
+		json.Unmarshal(resp["Message"], &message)
+		return nil, fmt.Errorf("cryptocompare error: %s", message)
+	}
+
+	q := &Quotes{Source: s.Name(), Prices: map[string]map[string]float64{}, FetchedAt: time.Now().UTC()}
+	for i, asset := range assets {
+		var byCurrency map[string]float64
+		if raw, ok := resp[syms[i]]; ok {
+			if err := json.Unmarshal(raw, &byCurrency); err != nil {
+				return nil, fmt.Errorf("error parsing prices of %s: %w", asset, err)
+			}
+		}
+		prices := map[string]float64{}
+		for currency, price := range byCurrency {
+			prices[strings.ToLower(currency)] = price
+		}
+		q.Prices[asset] = prices
+	}
+	return q, nil
+}
+
+// File reads prices from a JSON file in the format of the CoinGecko simple
+// price API, {"bitcoin": {"usd": 65000}}. The file is read on every call,
+// so it can be updated while the server runs.
+type File struct {
+	Path string
+}
+
+func (s *File) Name() string { return "file:" + s.Path }
+
+func (s *File) Prices(ctx context.Context, assets, currencies []string) (*Quotes, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]map[string]float64
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", s.Path, err)
+	}
+	q := &Quotes{Source: s.Name(), Prices: map[string]map[string]float64{}, FetchedAt: info.ModTime().UTC()}
+	for _, asset := range assets {
+		prices := map[string]float64{}
+		for _, currency := range currencies {
+			if price, ok := all[asset][currency]; ok {
+				prices[currency] = price
+			}
+		}
+		q.Prices[asset] = prices
+	}
+	return q, nil
+}
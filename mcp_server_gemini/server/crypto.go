@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/price"
+)
+
+// CryptoPriceArguments represent arguments of crypto_price tool
+type CryptoPriceArguments struct {
+	Coins      []string `json:"coins" jsonschema:"required,description=CoinGecko coin IDs such as bitcoin ethereum solana or dogecoin"`
+	Currencies []string `json:"currencies" jsonschema:"description=Currency codes such as usd eur or btc. Defaults to usd"`
+}
+
+const (
+	maxCoins      = 25
+	maxCurrencies = 10
+)
+
+// coinIDPattern matches CoinGecko coin IDs and currency codes.
+var coinIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// cryptoPrice returns the handler of the crypto_price tool.
+func cryptoPrice(prices price.Source) func(context.Context, CryptoPriceArguments) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, arguments CryptoPriceArguments) (*mcp_golang.ToolResponse, error) {
+		log.Printf("received request for crypto_price tool with coins %v in %v", arguments.Coins, arguments.Currencies)
+
+		coins, err := normalizeIDs("coin", arguments.Coins, maxCoins)
+		if err != nil {
+			return nil, err
+		}
+		if len(coins) == 0 {
+			return nil, fmt.Errorf("no coins given")
+		}
+		currencies, err := normalizeIDs("currency", arguments.Currencies, maxCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		if len(currencies) == 0 {
+			currencies = []string{"usd"}
+		}
+
+		quotes, err := prices.Prices(ctx, coins, currencies)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching prices: %w", err)
+		}
+		snapshot.record(quotes)
+
+		return toolResult("crypto_price", priceTable(quotes, coins, currencies), quotes)
+	}
+}
+
+// normalizeIDs lower-cases, checks and deduplicates coin IDs or currency
+// codes, keeping their order.
+func normalizeIDs(kind string, ids []string, limit int) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || seen[id] {
+			continue
+		}
+		if !coinIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid %s %q", kind, id)
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	if len(out) > limit {
+		return nil, fmt.Errorf("too many values for %s: %d, at most %d", kind, len(out), limit)
+	}
+	return out, nil
+}
+
+// priceTable renders quotes as a table with a row per coin and a column
+// per currency.
+func priceTable(q *price.Quotes, coins, currencies []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Prices %s:\n\n", provenance(q))
+
+	missing := false
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "coin\t")
+	for _, currency := range currencies {
+		fmt.Fprintf(w, "%s\t", strings.ToUpper(currency))
+	}
+	fmt.Fprintln(w)
+	for _, coin := range coins {
+		fmt.Fprintf(w, "%s\t", coin)
+		for _, currency := range currencies {
+			p, ok := q.Price(coin, currency)
+			if !ok {
+				fmt.Fprint(w, "-\t")
+				missing = true
+				continue
+			}
+			fmt.Fprintf(w, "%s\t", formatPrice(p))
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	if missing {
+		b.WriteString("\n- means that no source has this price\n")
+	}
+	return b.String()
+}
+
+// formatPrice shows two decimals for prices of one unit or more and every
+// digit below, so small coins do not round to zero.
+func formatPrice(p float64) string {
+	if a := math.Abs(p); a >= 1 || a == 0 {
+		return strconv.FormatFloat(p, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// provenance tells where and when quotes were fetched, and when they were
+// cached if they come from the cache.
+func provenance(q *price.Quotes) string {
+	s := fmt.Sprintf("as of %s, from %s", q.FetchedAt.Local().Format(time.RFC1123), q.Source)
+	if !q.CachedAt.IsZero() {
+		s += fmt.Sprintf(", cached_at %s", q.CachedAt.Local().Format(time.RFC1123))
+	}
+	return s
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/price"
+)
+
+// HistoricalPriceArguments represent arguments of historical_price tool
+type HistoricalPriceArguments struct {
+	Coin     string `json:"coin" jsonschema:"required,description=CoinGecko coin ID such as bitcoin"`
+	Currency string `json:"currency" jsonschema:"description=Currency code such as usd. Defaults to usd"`
+	Date     string `json:"date" jsonschema:"required,format=date,description=UTC day in YYYY-MM-DD format"`
+}
+
+// PriceRangeArguments represent arguments of price_range tool
+type PriceRangeArguments struct {
+	Coin     string `json:"coin" jsonschema:"required,description=CoinGecko coin ID such as bitcoin"`
+	Currency string `json:"currency" jsonschema:"description=Currency code such as usd. Defaults to usd"`
+	From     string `json:"from" jsonschema:"format=date,description=First UTC day in YYYY-MM-DD format. Defaults to 7 days before to"`
+	To       string `json:"to" jsonschema:"format=date,description=Last UTC day in YYYY-MM-DD format. Defaults to today"`
+}
+
+// maxRangeDays bounds the number of days of price_range.
+const maxRangeDays = 366
+
+// historicalPrice returns the handler of the historical_price tool.
+func historicalPrice(history price.History) func(context.Context, HistoricalPriceArguments) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, arguments HistoricalPriceArguments) (*mcp_golang.ToolResponse, error) {
+		log.Printf("received request for historical_price tool with %s in %s on %s", arguments.Coin, arguments.Currency, arguments.Date)
+
+		coin, currency, err := coinAndCurrency(arguments.Coin, arguments.Currency)
+		if err != nil {
+			return nil, err
+		}
+		date, err := parseDate("date", arguments.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		series, err := history.Candles(ctx, coin, currency, date, date)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching historical price: %w", err)
+		}
+		c := series.Candles[len(series.Candles)-1]
+
+		return toolResult("historical_price", fmt.Sprintf("%s in %s on %s: open %s, high %s, low %s, close %s (from %s)",
+			coin,
+			strings.ToUpper(currency),
+			c.Date.Format(price.DateLayout),
+			formatPrice(c.Open),
+			formatPrice(c.High),
+			formatPrice(c.Low),
+			formatPrice(c.Close),
+			series.Source), dayPrice{Asset: coin, Currency: currency, Source: series.Source, Candle: c})
+	}
+}
+
+// priceRange returns the handler of the price_range tool.
+func priceRange(history price.History) func(context.Context, PriceRangeArguments) (*mcp_golang.ToolResponse, error) {
+	return func(ctx context.Context, arguments PriceRangeArguments) (*mcp_golang.ToolResponse, error) {
+		log.Printf("received request for price_range tool with %s in %s from %s to %s", arguments.Coin, arguments.Currency, arguments.From, arguments.To)
+
+		coin, currency, err := coinAndCurrency(arguments.Coin, arguments.Currency)
+		if err != nil {
+			return nil, err
+		}
+		to := today()
+		if arguments.To != "" {
+			if to, err = parseDate("to", arguments.To); err != nil {
+				return nil, err
+			}
+		}
+		from := to.AddDate(0, 0, -7)
+		if arguments.From != "" {
+			if from, err = parseDate("from", arguments.From); err != nil {
+				return nil, err
+			}
+		}
+		if from.After(to) {
+			return nil, fmt.Errorf("from %s is after to %s", from.Format(price.DateLayout), to.Format(price.DateLayout))
+		}
+		if days := int(to.Sub(from).Hours()/24) + 1; days > maxRangeDays {
+			return nil, fmt.Errorf("range of %d days is too long, at most %d", days, maxRangeDays)
+		}
+
+		series, err := history.Candles(ctx, coin, currency, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching price range: %w", err)
+		}
+
+		return toolResult("price_range", rangeReport(series, coin, currency), rangePrices{
+			Asset:    coin,
+			Currency: currency,
+			Source:   series.Source,
+			Stats:    price.Summarize(series.Candles),
+			Candles:  series.Candles,
+		})
+	}
+}
+
+// rangeReport renders the stats of a series followed by its candles.
+func rangeReport(series *price.Series, coin, currency string) string {
+	s := price.Summarize(series.Candles)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s in %s from %s to %s (%d days, from %s):\n",
+		coin, strings.ToUpper(currency), s.From.Format(price.DateLayout), s.To.Format(price.DateLayout), len(series.Candles), series.Source)
+	fmt.Fprintf(&b, "open %s, close %s, change %s (%+.2f%%)\n", formatPrice(s.Open), formatPrice(s.Close), formatPrice(s.Change), s.ChangePercent)
+	fmt.Fprintf(&b, "min %s, max %s, mean close %s\n\n", formatPrice(s.Min), formatPrice(s.Max), formatPrice(s.Mean))
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "date\topen\thigh\tlow\tclose\t")
+	for _, c := range series.Candles {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", c.Date.Format(price.DateLayout), formatPrice(c.Open), formatPrice(c.High), formatPrice(c.Low), formatPrice(c.Close))
+	}
+	w.Flush()
+	return b.String()
+}
+
+// coinAndCurrency checks a coin ID and a currency code, defaulting the
+// currency to usd.
+func coinAndCurrency(coin, currency string) (string, string, error) {
+	coins, err := normalizeIDs("coin", []string{coin}, 1)
+	if err != nil {
+		return "", "", err
+	}
+	if len(coins) == 0 {
+		return "", "", fmt.Errorf("no coin given")
+	}
+	currencies, err := normalizeIDs("currency", []string{currency}, 1)
+	if err != nil {
+		return "", "", err
+	}
+	if len(currencies) == 0 {
+		currencies = []string{"usd"}
+	}
+	return coins[0], currencies[0], nil
+}
+
+// parseDate parses a YYYY-MM-DD date argument, which cannot be in the
+// future.
+func parseDate(name, value string) (time.Time, error) {
+	date, err := time.Parse(price.DateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected YYYY-MM-DD", name, value)
+	}
+	if date.After(today()) {
+		return time.Time{}, fmt.Errorf("%s %s is in the future, today is %s", name, value, today().Format(price.DateLayout))
+	}
+	return date, nil
+}
+
+// today returns the start of the current UTC day.
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
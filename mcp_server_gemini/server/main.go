@@ -1,15 +1,23 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	mcp_golang "github.com/metoro-io/mcp-golang"
+	"github.com/metoro-io/mcp-golang/transport"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
+
+	"example.com/mcp-server/mcpgemini/streamhttp"
+	"example.com/mcp-server/price"
 )
 
 // HelloArgs represent arguments of hello tool
@@ -18,22 +26,13 @@ type HelloArgs struct {
 }
 
 type BitcoinPriceArguments struct {
-	Currency string `json:"currency" jsonschema:"required,description=The currency to get the Bitcoin price in USD, EUR, GBP, JPY, AUD, CAD, CHF, CNY, KRW, RUB"`
+	Currency string `json:"currency" jsonschema:"required,description=The currency to get the Bitcoin price in,enum=USD,enum=EUR,enum=GBP,enum=JPY,enum=AUD,enum=CAD,enum=CHF,enum=CNY,enum=KRW,enum=RUB"`
 }
 
-type CoinGeckoResponse struct {
-	Bitcoin struct {
-		USD float64 `json:"usd"`
-		EUR float64 `json:"eur"`
-		GBP float64 `json:"gbp"`
-		JPY float64 `json:"jpy"`
-		AUD float64 `json:"aud"`
-		CAD float64 `json:"cad"`
-		CHF float64 `json:"chf"`
-		CNY float64 `json:"cny"`
-		KRW float64 `json:"krw"`
-		RUB float64 `json:"rub"`
-	} `json:"bitcoin"`
+// MarketBriefArgs represent arguments of the market_brief prompt
+type MarketBriefArgs struct {
+	Currency string  `json:"currency" jsonschema:"required,description=The currency to quote prices in"`
+	Style    *string `json:"style" jsonschema:"description=How to phrase the answers, short by default"`
 }
 
 type Content struct {
@@ -41,67 +40,64 @@ type Content struct {
 	Description *string `json:"description" jsonschema:"description=The description to submit"`
 }
 
-func getBitcoinPrice(currency string) (float64, error) {
-	log.Printf("INSIDE GET BITCOIN PRICE - CURRENCY - %s", currency)
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+// eofReader reads from r and closes done once r reaches EOF, which is how
+// the client tells a stdio server that the session is over.
+type eofReader struct {
+	r    io.Reader
+	done chan struct{}
+	once sync.Once
+}
 
-	// Make request to CoinGecko API
-	resp, err := client.Get("https://api.coingecko.com/api/v3/simple/price?ids=bitcoin&vs_currencies=usd,eur,gbp,jpy,aud,cad,chf,cny,krw,rub")
+func (e *eofReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
 	if err != nil {
-		return 0, fmt.Errorf("error making request to CoinGecko API: %w", err)
+		e.once.Do(func() { close(e.done) })
 	}
-	defer resp.Body.Close()
+	return n, err
+}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+func main() {
+	transportName := flag.String("transport", "stdio", "transport to serve on: stdio or http")
+	addr := flag.String("addr", ":8080", "address to listen on with the http transport")
+	priceConfig := flag.String("price-config", "", "JSON file listing the price sources to try in order, defaults to CoinGecko, Coinbase and CryptoCompare")
+	historyDir := flag.String("history-dir", "", "directory of historical price files to serve as resources, defaults to data next to the binary, server/data above its bin directory or server/data in the current directory")
+	flag.Parse()
+
+	cfg := price.DefaultConfig()
+	if *priceConfig != "" {
+		var err error
+		cfg, err = price.LoadConfig(*priceConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	prices, err := cfg.Source()
 	if err != nil {
-		return 0, fmt.Errorf("error reading response body: %w", err)
+		log.Fatal(err)
 	}
-
-	// Parse JSON response
-	var coinGeckoResp CoinGeckoResponse
-	err = json.Unmarshal(body, &coinGeckoResp)
+	history, err := cfg.History()
 	if err != nil {
-		return 0, fmt.Errorf("error parsing JSON response: %w", err)
-	}
-
-	// Get price for requested currency
-	var price float64
-	switch currency {
-	case "USD", "usd":
-		price = coinGeckoResp.Bitcoin.USD
-	case "EUR", "eur":
-		price = coinGeckoResp.Bitcoin.EUR
-	case "GBP", "gbp":
-		price = coinGeckoResp.Bitcoin.GBP
-	case "JPY", "jpy":
-		price = coinGeckoResp.Bitcoin.JPY
-	case "AUD", "aud":
-		price = coinGeckoResp.Bitcoin.AUD
-	case "CAD", "cad":
-		price = coinGeckoResp.Bitcoin.CAD
-	case "CHF", "chf":
-		price = coinGeckoResp.Bitcoin.CHF
-	case "CNY", "cny":
-		price = coinGeckoResp.Bitcoin.CNY
-	case "KRW", "krw":
-		price = coinGeckoResp.Bitcoin.KRW
-	case "RUB", "rub":
-		price = coinGeckoResp.Bitcoin.RUB
-	default:
-		return 0, fmt.Errorf("unsupported currency: %s", currency)
+		log.Fatal(err)
 	}
 
-	return price, nil
-}
-
-func main() {
-	server := mcp_golang.NewServer(stdio.NewStdioServerTransport())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// done is closed when the client ends a stdio session; it stays open
+	// over HTTP, where the server runs until it is signalled.
+	done := make(chan struct{})
+	var t transport.Transport
+	switch *transportName {
+	case "stdio":
+		t = stdio.NewStdioServerTransportWithIO(&eofReader{r: os.Stdin, done: done}, os.Stdout)
+	case "http":
+		t = streamhttp.NewServer(*addr)
+	default:
+		log.Fatalf("unknown transport %s", *transportName)
+	}
+	server := mcp_golang.NewServer(t)
 
-	err := server.RegisterTool("hello", "Say hello to a person", func(args HelloArgs) (*mcp_golang.ToolResponse, error) {
+	err = server.RegisterTool("hello", "Say hello to a person", func(args HelloArgs) (*mcp_golang.ToolResponse, error) {
 		message := fmt.Sprintf("Hello %s!", args.Name)
 		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(message)), nil
 	})
@@ -110,7 +106,7 @@ func main() {
 	}
 
 	// Register the bitcoin_price tool
-	err = server.RegisterTool("bitcoin_price", "Get the latest Bitcoin price in various currencies", func(arguments BitcoinPriceArguments) (*mcp_golang.ToolResponse, error) {
+	err = server.RegisterTool("bitcoin_price", "Get the latest Bitcoin price in various currencies", func(ctx context.Context, arguments BitcoinPriceArguments) (*mcp_golang.ToolResponse, error) {
 		log.Printf("received request for bitcoin_price tool with currency: %s", arguments.Currency)
 
 		currency := arguments.Currency
@@ -118,16 +114,29 @@ func main() {
 			currency = "USD"
 		}
 
-		// Call CoinGecko API to get latest Bitcoin price
-		price, err := getBitcoinPrice(currency)
+		// Ask the configured price sources for the latest Bitcoin price
+		quotes, err := prices.Prices(ctx, []string{"bitcoin"}, []string{strings.ToLower(currency)})
 		if err != nil {
 			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("Error fetching Bitcoin price: %v", err))), err
 		}
+		btc, ok := quotes.Price("bitcoin", strings.ToLower(currency))
+		if !ok {
+			return nil, fmt.Errorf("no Bitcoin price in %s", currency)
+		}
+
+		snapshot.record(quotes)
 
-		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(fmt.Sprintf("The current Bitcoin price in %s is %.2f (as of %s)",
+		return toolResult("bitcoin_price", fmt.Sprintf("The current Bitcoin price in %s is %.2f (%s)",
 			currency,
-			price,
-			time.Now().Format(time.RFC1123)))), nil
+			btc,
+			provenance(quotes)), spotPrice{
+			Asset:     "bitcoin",
+			Currency:  strings.ToLower(currency),
+			Price:     btc,
+			Source:    quotes.Source,
+			FetchedAt: quotes.FetchedAt,
+			CachedAt:  quotes.CachedAt,
+		})
 	})
 	if err != nil {
 		log.Fatalf("error registering bitcoin_price tool: %v", err)
@@ -142,10 +151,59 @@ func main() {
 		log.Fatalf("error registering prompt_test prompt: %v", err)
 	}
 
+	err = server.RegisterPrompt("market_brief", "Set up a conversation about the Bitcoin market in one currency", func(arguments MarketBriefArgs) (*mcp_golang.PromptResponse, error) {
+		style := "short"
+		if arguments.Style != nil && *arguments.Style != "" {
+			style = *arguments.Style
+		}
+
+		return mcp_golang.NewPromptResponse("Bitcoin market brief",
+			mcp_golang.NewPromptMessage(mcp_golang.NewTextContent(fmt.Sprintf("I follow the Bitcoin market in %s. Keep your answers %s.", arguments.Currency, style)), mcp_golang.RoleUser),
+			mcp_golang.NewPromptMessage(mcp_golang.NewTextContent(fmt.Sprintf("Understood. I will quote Bitcoin prices in %s with the bitcoin_price tool and keep my answers %s.", arguments.Currency, style)), mcp_golang.RoleAssistant),
+		), nil
+	})
+	if err != nil {
+		log.Fatalf("error registering market_brief prompt: %v", err)
+	}
+
+	err = server.RegisterTool("crypto_price", "Get the latest prices of any cryptocurrencies in any currencies, as a table", cryptoPrice(prices))
+	if err != nil {
+		log.Fatalf("error registering crypto_price tool: %v", err)
+	}
+
+	err = server.RegisterTool("historical_price", "Get the open, high, low and close price of a cryptocurrency on a past day", historicalPrice(history))
+	if err != nil {
+		log.Fatalf("error registering historical_price tool: %v", err)
+	}
+
+	err = server.RegisterTool("price_range", "Get the daily OHLC prices of a cryptocurrency over a period with its min, max, mean and percent change", priceRange(history))
+	if err != nil {
+		log.Fatalf("error registering price_range tool: %v", err)
+	}
+
+	if *historyDir == "" {
+		var tried []string
+		if *historyDir, tried = findHistoryDir(); *historyDir == "" {
+			log.Printf("warning: no history directory in %s, no historical price resources; set -history-dir", strings.Join(tried, ", "))
+		}
+	}
+	if err := registerResources(server, *historyDir); err != nil {
+		log.Fatal(err)
+	}
+
 	err = server.Serve()
 	if err != nil {
 		panic(err)
 	}
+	if *transportName == "http" {
+		log.Printf("serving MCP on http://%s%s", *addr, streamhttp.DefaultEndpoint)
+	}
 
-	select {}
+	select {
+	case <-done:
+		log.Println("stdin closed, shutting down")
+	case <-ctx.Done():
+		log.Println("received signal, shutting down")
+	}
+	t.Close()
 }
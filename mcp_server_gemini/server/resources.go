@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/price"
+)
+
+// supportedCurrencies are the currencies bitcoin_price accepts, in the
+// order of its schema enum.
+var supportedCurrencies = []string{"USD", "EUR", "GBP", "JPY", "AUD", "CAD", "CHF", "CNY", "KRW", "RUB"}
+
+// snapshotPrice is the last price fetched for an asset in a currency.
+type snapshotPrice struct {
+	Price     float64   `json:"price"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// priceSnapshot keeps the latest price fetched by the price tools for
+// every asset and currency, served as the prices://snapshot/latest
+// resource.
+type priceSnapshot struct {
+	mu     sync.Mutex
+	prices map[string]map[string]snapshotPrice
+}
+
+var snapshot = &priceSnapshot{prices: map[string]map[string]snapshotPrice{}}
+
+func (s *priceSnapshot) record(q *price.Quotes) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for asset, prices := range q.Prices {
+		if len(prices) == 0 {
+			continue
+		}
+		if s.prices[asset] == nil {
+			s.prices[asset] = map[string]snapshotPrice{}
+		}
+		for currency, p := range prices {
+			s.prices[asset][currency] = snapshotPrice{Price: p, Source: q.Source, FetchedAt: q.FetchedAt}
+		}
+	}
+}
+
+func (s *priceSnapshot) json() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.MarshalIndent(map[string]any{"prices": s.prices}, "", "  ")
+}
+
+// registerResources registers the currency list, the price snapshot and
+// every file in historyDir as resources.
+func registerResources(server *mcp_golang.Server, historyDir string) error {
+	err := server.RegisterResource("prices://currencies", "currencies", "Currencies supported by the bitcoin_price tool", "application/json", func() (*mcp_golang.ResourceResponse, error) {
+		data, err := json.Marshal(supportedCurrencies)
+		if err != nil {
+			return nil, err
+		}
+		return mcp_golang.NewResourceResponse(mcp_golang.NewTextEmbeddedResource("prices://currencies", string(data), "application/json")), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error registering currencies resource: %w", err)
+	}
+
+	err = server.RegisterResource("prices://snapshot/latest", "latest prices", "Latest prices fetched by the price tools, per asset and currency", "application/json", func() (*mcp_golang.ResourceResponse, error) {
+		data, err := snapshot.json()
+		if err != nil {
+			return nil, err
+		}
+		return mcp_golang.NewResourceResponse(mcp_golang.NewTextEmbeddedResource("prices://snapshot/latest", string(data), "application/json")), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error registering snapshot resource: %w", err)
+	}
+
+	if historyDir == "" {
+		return nil
+	}
+	if info, err := os.Stat(historyDir); err != nil || !info.IsDir() {
+		log.Printf("warning: history directory %s not found, no historical price resources", historyDir)
+		return nil
+	}
+	entries, err := os.ReadDir(historyDir)
+	if err != nil {
+		return fmt.Errorf("error reading history directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(historyDir, entry.Name())
+		uri := "prices://history/" + entry.Name()
+		mimeType := mime.TypeByExtension(filepath.Ext(entry.Name()))
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+
+		err := server.RegisterResource(uri, entry.Name(), "Historical Bitcoin prices from "+entry.Name(), mimeType, func() (*mcp_golang.ResourceResponse, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", entry.Name(), err)
+			}
+			return mcp_golang.NewResourceResponse(mcp_golang.NewTextEmbeddedResource(uri, string(data), mimeType)), nil
+		})
+		if err != nil {
+			return fmt.Errorf("error registering %s: %w", uri, err)
+		}
+	}
+	return nil
+}
+
+// findHistoryDir returns the first directory of sample price history
+// found next to the server binary, in the source tree above a binary
+// built into bin/ by `client build`, or under the current directory for
+// `go run ./server`. It returns "" and the places it looked in when there
+// is none.
+func findHistoryDir() (string, []string) {
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		if exe, err := filepath.EvalSymlinks(exe); err == nil {
+			dir := filepath.Dir(exe)
+			candidates = append(candidates, filepath.Join(dir, "data"), filepath.Join(dir, "..", "server", "data"))
+		}
+	}
+	candidates = append(candidates, filepath.Join("server", "data"))
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", candidates
+}
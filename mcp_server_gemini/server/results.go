@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mcp_golang "github.com/metoro-io/mcp-golang"
+
+	"example.com/mcp-server/price"
+)
+
+// spotPrice is the structured result of bitcoin_price.
+type spotPrice struct {
+	Asset     string    `json:"asset"`
+	Currency  string    `json:"currency"`
+	Price     float64   `json:"price"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+	CachedAt  time.Time `json:"cached_at,omitzero"`
+}
+
+// dayPrice is the structured result of historical_price.
+type dayPrice struct {
+	Asset    string `json:"asset"`
+	Currency string `json:"currency"`
+	Source   string `json:"source"`
+	price.Candle
+}
+
+// rangePrices is the structured result of price_range.
+type rangePrices struct {
+	Asset    string         `json:"asset"`
+	Currency string         `json:"currency"`
+	Source   string         `json:"source"`
+	Stats    price.Stats    `json:"stats"`
+	Candles  []price.Candle `json:"candles"`
+}
+
+// toolResult returns text for people along with v as an application/json
+// resource, which the client hands to the model as is.
+func toolResult(tool, text string, v any) (*mcp_golang.ToolResponse, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s result: %w", tool, err)
+	}
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(text),
+		mcp_golang.NewTextResourceContent("prices://result/"+tool, string(data), "application/json"),
+	), nil
+}
@@ -0,0 +1,143 @@
+package upstream
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every request through.
+	Closed State = iota
+	// Open rejects every request until the cooldown has passed.
+	Open
+	// HalfOpen lets a single probe through; its outcome closes or reopens
+	// the circuit.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Breaker is a circuit breaker that opens after Threshold consecutive
+// failures and probes the upstream again after Cooldown.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed circuit breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// OpenError is returned by Allow while the circuit is open.
+type OpenError struct {
+	State    State
+	Failures int
+	// RetryIn is how long until the circuit lets a probe through.
+	RetryIn time.Duration
+}
+
+func (e *OpenError) Error() string {
+	if e.State == HalfOpen {
+		return "circuit half-open, waiting for a probe request"
+	}
+	return fmt.Sprintf("circuit open after %d consecutive failures, retry in %s", e.Failures, e.RetryIn.Round(time.Second))
+}
+
+// Allow reports whether a request may be sent. In the half-open state
+// only the first caller gets through, and must call Record or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case Open:
+		return &OpenError{State: Open, Failures: b.failures, RetryIn: b.Cooldown - time.Since(b.openedAt)}
+	case HalfOpen:
+		if b.probing {
+			return &OpenError{State: HalfOpen, Failures: b.failures}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record records the outcome of a request let through by Allow.
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+
+	if !failed {
+		b.state, b.failures = Closed, 0
+		return
+	}
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.Threshold {
+		b.state, b.openedAt = Open, time.Now()
+	}
+}
+
+// Release gives up a request let through by Allow without an outcome,
+// such as one cancelled by its caller.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// String describes the state for error messages.
+func (b *Breaker) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case Closed:
+		if b.failures == 0 {
+			return "circuit closed"
+		}
+		return fmt.Sprintf("circuit closed, %d/%d failures", b.failures, b.Threshold)
+	case Open:
+		return fmt.Sprintf("circuit open for %s more", (b.Cooldown - time.Since(b.openedAt)).Round(time.Second))
+	default:
+		return "circuit " + b.state.String()
+	}
+}
+
+// advance moves an open circuit to half-open once the cooldown has passed.
+func (b *Breaker) advance() {
+	if b.state == Open && time.Since(b.openedAt) >= b.Cooldown {
+		b.state = HalfOpen
+	}
+}
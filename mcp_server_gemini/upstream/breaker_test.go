@@ -0,0 +1,97 @@
+package upstream
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewBreaker(3, time.Minute)
+	for i := range 3 {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("request %d: %v while closed", i+1, err)
+		}
+		b.Record(true)
+	}
+	if b.State() != Open {
+		t.Fatalf("state = %s after 3 failures, want open", b.State())
+	}
+
+	var open *OpenError
+	if err := b.Allow(); !errors.As(err, &open) || open.State != Open || open.Failures != 3 {
+		t.Fatalf("Allow = %v, want an open error after 3 failures", err)
+	}
+	if !strings.HasPrefix(b.String(), "circuit open for ") {
+		t.Errorf("String = %q", b.String())
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewBreaker(3, time.Minute)
+	b.Record(true)
+	b.Record(true)
+	if got := b.String(); got != "circuit closed, 2/3 failures" {
+		t.Errorf("String = %q", got)
+	}
+	b.Record(false)
+	b.Record(true)
+	b.Record(true)
+	if b.State() != Closed {
+		t.Errorf("state = %s, want closed as failures were not consecutive", b.State())
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b := NewBreaker(1, 10*time.Millisecond)
+	b.Record(true)
+	if b.State() != Open {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s after the cooldown, want half-open", b.State())
+	}
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe not let through: %v", err)
+	}
+	var open *OpenError
+	if err := b.Allow(); !errors.As(err, &open) || open.State != HalfOpen {
+		t.Fatalf("second request = %v, want it held back during the probe", err)
+	}
+
+	// A failed probe reopens the circuit.
+	b.Record(true)
+	if b.State() != Open {
+		t.Fatalf("state = %s after a failed probe, want open", b.State())
+	}
+
+	// A successful one closes it.
+	time.Sleep(20 * time.Millisecond)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe not let through: %v", err)
+	}
+	b.Record(false)
+	if b.State() != Closed || b.String() != "circuit closed" {
+		t.Fatalf("state = %s (%s) after a good probe, want closed", b.State(), b)
+	}
+}
+
+func TestBreakerRelease(t *testing.T) {
+	b := NewBreaker(1, 10*time.Millisecond)
+	b.Record(true)
+	time.Sleep(20 * time.Millisecond)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe not let through: %v", err)
+	}
+	b.Release()
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s after a released probe, want half-open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("next probe not let through after Release: %v", err)
+	}
+}
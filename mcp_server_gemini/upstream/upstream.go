@@ -0,0 +1,273 @@
+// Package upstream is the outbound HTTP layer of the server tools. A
+// Client talks to one upstream API: it checks response status codes,
+// retries transient failures with jittered backoff honouring Retry-After,
+// spaces requests with a token bucket and stops calling an API that keeps
+// failing with a circuit breaker, whose state is part of every error.
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultTimeout          = 10 * time.Second
+	DefaultMaxRetries       = 3
+	DefaultMinBackoff       = 500 * time.Millisecond
+	DefaultMaxBackoff       = 10 * time.Second
+	DefaultMaxRetryAfter    = 30 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultCooldown         = 30 * time.Second
+	DefaultMaxBodySize      = 4 << 20
+)
+
+// Client sends requests to a single upstream API.
+type Client struct {
+	// Name identifies the upstream in errors.
+	Name string
+	HTTP *http.Client
+	// Limiter, if set, spaces out requests, retries included.
+	Limiter *rate.Limiter
+	// Breaker must be set, New sets a default one.
+	Breaker *Breaker
+	// MaxRetries bounds the retries of a request after its first attempt.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential backoff between
+	// attempts, which is fully jittered.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest Retry-After the client waits for;
+	// longer ones fail the request at once.
+	MaxRetryAfter time.Duration
+	MaxBodySize   int64
+}
+
+// New returns a client for the upstream name with the default timeout,
+// retries and circuit breaker, and no rate limit.
+func New(name string) *Client {
+	return &Client{
+		Name:          name,
+		HTTP:          &http.Client{Timeout: DefaultTimeout},
+		Breaker:       NewBreaker(DefaultFailureThreshold, DefaultCooldown),
+		MaxRetries:    DefaultMaxRetries,
+		MinBackoff:    DefaultMinBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		MaxRetryAfter: DefaultMaxRetryAfter,
+		MaxBodySize:   DefaultMaxBodySize,
+	}
+}
+
+// StatusError is a response with a status other than 2xx.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	// RetryAfter is the delay asked for by the Retry-After header.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	msg := "unexpected status " + e.Status
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Error is a failed request, with the state of the circuit breaker of
+// the upstream after it. The message leaves the upstream name to the
+// caller.
+type Error struct {
+	Upstream string
+	Circuit  string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	var open *OpenError
+	if errors.As(e.Err, &open) {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (%s)", e.Err, e.Circuit)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// GetJSON fetches url and decodes its JSON response into v.
+func (c *Client) GetJSON(ctx context.Context, url string, header http.Header, v any) error {
+	h := header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Accept", "application/json")
+
+	body, resp, err := c.Get(ctx, url, h)
+	if err != nil {
+		return err
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mediaType, _, _ := mime.ParseMediaType(ct); !strings.HasSuffix(mediaType, "json") {
+			return c.wrap(fmt.Errorf("unexpected content type %s", ct))
+		}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return c.wrap(fmt.Errorf("error parsing JSON response: %w", err))
+	}
+	return nil
+}
+
+// Get fetches url and returns the body of its 2xx response, retrying
+// transient failures.
+func (c *Client) Get(ctx context.Context, url string, header http.Header) ([]byte, *http.Response, error) {
+	var last error
+	for attempt := 0; ; attempt++ {
+		if err := c.Breaker.Allow(); err != nil {
+			// A retry stopped by the breaker reports what went wrong.
+			if last != nil {
+				return nil, nil, c.wrap(last)
+			}
+			return nil, nil, c.wrap(err)
+		}
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(ctx); err != nil {
+				c.Breaker.Release()
+				return nil, nil, c.wrap(fmt.Errorf("rate limited: %w", err))
+			}
+		}
+
+		body, resp, err := c.get(ctx, url, header)
+		if err == nil {
+			c.Breaker.Record(false)
+			return body, resp, nil
+		}
+		if ctx.Err() != nil {
+			c.Breaker.Release()
+			return nil, nil, c.wrap(err)
+		}
+		last = err
+		transient := retryable(err)
+		c.Breaker.Record(transient)
+		if !transient || attempt >= c.MaxRetries {
+			return nil, nil, c.wrap(err)
+		}
+
+		wait := c.backoff(attempt)
+		var status *StatusError
+		if errors.As(err, &status) && status.RetryAfter > 0 {
+			if status.RetryAfter > c.MaxRetryAfter {
+				return nil, nil, c.wrap(fmt.Errorf("%w, retry after %s", err, status.RetryAfter))
+			}
+			wait = status.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, nil, c.wrap(err)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, nil, c.wrap(err)
+		}
+	}
+}
+
+// get makes a single attempt.
+func (c *Client) get(ctx context.Context, url string, header http.Header) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header = header.Clone()
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	limit := c.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       snippet(body),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return body, resp, nil
+}
+
+func (c *Client) wrap(err error) error {
+	return &Error{Upstream: c.Name, Circuit: c.Breaker.String(), Err: err}
+}
+
+// backoff returns a random delay up to MinBackoff doubled attempt times,
+// capped by MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.MinBackoff << attempt
+	if ceiling <= 0 || ceiling > c.MaxBackoff {
+		ceiling = c.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// retryable reports whether err is worth another attempt: network errors,
+// rate limiting and server errors.
+func retryable(err error) bool {
+	var status *StatusError
+	if !errors.As(err, &status) {
+		return true
+	}
+	switch status.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// snippet shortens a response body for an error message.
+func snippet(body []byte) string {
+	s := strings.Join(strings.Fields(string(body)), " ")
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
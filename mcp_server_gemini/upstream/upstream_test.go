@@ -0,0 +1,237 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// newTestClient returns a client with short backoffs.
+func newTestClient() *Client {
+	c := New("test")
+	c.MinBackoff = time.Millisecond
+	c.MaxBackoff = 5 * time.Millisecond
+	return c
+}
+
+// serve answers the nth request, counting from 0, with handlers[n], and
+// any later request with the last handler.
+func serve(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(n.Add(1)) - 1
+		handlers[min(i, len(handlers)-1)](w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func status(code int, header map[string]string, contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}
+}
+
+const tooManyRequestsPage = "<html><body><h1>429 Too Many Requests</h1></body></html>"
+
+func TestGetJSONRetriesTransientErrors(t *testing.T) {
+	srv, requests := serve(t,
+		status(http.StatusServiceUnavailable, nil, "text/plain", "down"),
+		status(http.StatusTooManyRequests, nil, "text/html", tooManyRequestsPage),
+		status(http.StatusOK, nil, "application/json", `{"bitcoin":{"usd":65000}}`),
+	)
+
+	var v map[string]map[string]float64
+	if err := newTestClient().GetJSON(context.Background(), srv.URL, nil, &v); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if v["bitcoin"]["usd"] != 65000 {
+		t.Errorf("got %v", v)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+}
+
+func TestGetJSONRateLimitPage(t *testing.T) {
+	srv, requests := serve(t, status(http.StatusTooManyRequests, nil, "text/html", tooManyRequestsPage))
+
+	c := newTestClient()
+	var v map[string]any
+	err := c.GetJSON(context.Background(), srv.URL, nil, &v)
+
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 status error rather than a parsed page", err)
+	}
+	if !strings.Contains(status.Body, "429 Too Many Requests") {
+		t.Errorf("body = %q, want a snippet of the page", status.Body)
+	}
+	if n := requests.Load(); n != int32(c.MaxRetries)+1 {
+		t.Errorf("got %d requests, want %d", n, c.MaxRetries+1)
+	}
+	var upstreamErr *Error
+	if !errors.As(err, &upstreamErr) || !strings.HasSuffix(err.Error(), "(circuit closed, 4/5 failures)") {
+		t.Errorf("err = %q, want the circuit state", err)
+	}
+}
+
+func TestGetJSONRejectsNonJSON(t *testing.T) {
+	srv, _ := serve(t, status(http.StatusOK, nil, "text/html", tooManyRequestsPage))
+
+	var v map[string]any
+	err := newTestClient().GetJSON(context.Background(), srv.URL, nil, &v)
+	if err == nil || !strings.Contains(err.Error(), "unexpected content type text/html") {
+		t.Fatalf("err = %v, want an unexpected content type", err)
+	}
+}
+
+func TestGetDoesNotRetryClientErrors(t *testing.T) {
+	srv, requests := serve(t, status(http.StatusNotFound, nil, "application/json", `{"error":"coin not found"}`))
+
+	c := newTestClient()
+	_, _, err := c.Get(context.Background(), srv.URL, nil)
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want a 404 status error", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+	if c.Breaker.State() != Closed || c.Breaker.String() != "circuit closed" {
+		t.Errorf("breaker = %s, a 404 is not an upstream failure", c.Breaker)
+	}
+}
+
+func TestGetHonoursRetryAfter(t *testing.T) {
+	srv, requests := serve(t,
+		status(http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}, "text/plain", "slow down"),
+		status(http.StatusOK, nil, "application/json", `{}`),
+	)
+
+	start := time.Now()
+	if _, _, err := newTestClient().Get(context.Background(), srv.URL, nil); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the Retry-After of 1s", elapsed)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestGetFailsOnLongRetryAfter(t *testing.T) {
+	srv, requests := serve(t, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}, "text/plain", "slow down"))
+
+	c := newTestClient()
+	c.MaxRetryAfter = 30 * time.Second
+	start := time.Now()
+	_, _, err := c.Get(context.Background(), srv.URL, nil)
+	if err == nil || !strings.Contains(err.Error(), "retry after 2m0s") {
+		t.Fatalf("err = %v, want a failure naming the Retry-After", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("failed after %s, want at once", elapsed)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestGetStopsRetryingWhenCircuitOpens(t *testing.T) {
+	srv, requests := serve(t, status(http.StatusBadGateway, nil, "text/plain", "bad gateway"))
+
+	c := newTestClient()
+	c.Breaker = NewBreaker(2, time.Minute)
+	_, _, err := c.Get(context.Background(), srv.URL, nil)
+
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want the last 502 rather than the open circuit", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2 before the circuit opened", n)
+	}
+
+	_, _, err = c.Get(context.Background(), srv.URL, nil)
+	var open *OpenError
+	if !errors.As(err, &open) {
+		t.Fatalf("err = %v, want the open circuit", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want none while the circuit is open", n)
+	}
+}
+
+func TestGetWaitsForLimiter(t *testing.T) {
+	srv, requests := serve(t, status(http.StatusOK, nil, "application/json", `{}`))
+
+	c := newTestClient()
+	c.Limiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+	if _, _, err := c.Get(context.Background(), srv.URL, nil); err != nil {
+		t.Fatalf("first Get: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err := c.Get(ctx, srv.URL, nil)
+	if err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("err = %v, want the limiter to hold the request back", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+	if c.Breaker.String() != "circuit closed" {
+		t.Errorf("breaker = %s, a rate-limited request is not a failure", c.Breaker)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.value); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(date); got < 58*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %s, want about a minute", date, got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt := range 10 {
+		ceiling := min(c.MinBackoff<<attempt, c.MaxBackoff)
+		for range 20 {
+			if got := c.backoff(attempt); got <= 0 || got > ceiling {
+				t.Fatalf("backoff(%d) = %s, want within (0, %s]", attempt, got, ceiling)
+			}
+		}
+	}
+}